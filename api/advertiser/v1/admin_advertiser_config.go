@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"encoding/json"
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/response"
+	"go-boilerplate/domain/advertiser"
+	advertiserFacade "go-boilerplate/facade/advertiser"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminAdvertiserConfigGetHandler handle admin get requests of the portals config of an advertiser
+// @Tags Advertiser
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "advertiser id"
+// @Success 200 {object} advertiser.Config
+// @Failure 401 {object} response.Error "When the request has no valid admin bearer token"
+// @Failure 404 {object} response.Error "When the advertiser isn't configured"
+// @Failure 500 {object} response.Error "When something was wrong when trying to load the configs"
+// @Router /v1/admin/advertiser/{id}/config [get]
+func AdminAdvertiserConfigGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	result, err := advertiserFacade.Get().FindByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteError(w, r, err, "error finding advertiser config")
+		return
+	}
+
+	response.Write(w, r, result, http.StatusOK)
+}
+
+// AdminAdvertiserConfigPutHandler handle admin put requests of the portals config of an advertiser,
+// each update creates a new version of the configs of all advertisers which is returned as the ETag
+// @Tags Advertiser
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "advertiser id"
+// @Param config body advertiser.Config true "payload"
+// @Success 200 {object} advertiser.Config
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid admin bearer token"
+// @Failure 422 {object} response.Error "When the payload is malformed"
+// @Failure 500 {object} response.Error "When something was wrong when trying to save the configs"
+// @Router /v1/admin/advertiser/{id}/config [put]
+func AdminAdvertiserConfigPutHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	body := advertiser.Config{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.WriteUnprocessableEntity(w, err)
+		return
+	}
+	body.AdvertiserID = mux.Vars(r)["id"]
+	if err := body.Validate(); err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.WriteUnauthorizedError(w)
+		return
+	}
+
+	version, err := advertiserFacade.Get().Update(r.Context(), body, principal.AccountID)
+	if err != nil {
+		response.WriteError(w, r, err, "error updating advertiser config")
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+	response.Write(w, r, body, http.StatusOK)
+}
+
+// AdminAdvertiserConfigVersionGetHandler handle admin get requests of a version of the configs of all advertisers
+// @Tags Advertiser
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param version path int true "version" Format(int)
+// @Success 200 {object} advertiser.Configs
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid admin bearer token"
+// @Failure 404 {object} response.Error "When the version was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to load the configs"
+// @Router /v1/admin/advertiser/config/versions/{version} [get]
+func AdminAdvertiserConfigVersionGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	result, err := advertiserFacade.Get().FindVersion(r.Context(), version)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding advertiser configs version")
+		return
+	}
+
+	response.Write(w, r, result, http.StatusOK)
+}
@@ -0,0 +1,37 @@
+// Package v1 holds advertiser v1 api handlers
+package v1
+
+import (
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/response"
+	advertiserFacade "go-boilerplate/facade/advertiser"
+	"net/http"
+)
+
+// AdvertiserConfigGetHandler handle get requests of the portals config of the authenticated advertiser
+// @Tags Advertiser
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} advertiser.Config
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 404 {object} response.Error "When the advertiser isn't configured"
+// @Failure 500 {object} response.Error "When something was wrong when trying to load the configs"
+// @Router /v1/advertiser/config [get]
+func AdvertiserConfigGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.WriteUnauthorizedError(w)
+		return
+	}
+
+	result, err := advertiserFacade.Get().FindByID(r.Context(), principal.AdvertiserID)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding advertiser config")
+		return
+	}
+
+	response.Write(w, r, result, http.StatusOK)
+}
@@ -0,0 +1,77 @@
+package api_test
+
+import (
+	"go-boilerplate/common/auth"
+	"go-boilerplate/test"
+	"net/http"
+	"testing"
+)
+
+func TestAdvertiserRoutes(t *testing.T) {
+	advertiserID := "6f1c2d3e-4b5a-4c6d-8e7f-9a0b1c2d3e4f"
+	token, err := auth.GenerateToken("34178e2a-b9be-48ef-bfb4-3973747ae257", advertiserID, 1)
+	if err != nil {
+		t.Fatalf("error generating access token %s", err)
+	}
+	adminToken, err := auth.GenerateAdminToken("b7e1a3c4-2d5f-4e6a-9b8c-0d1e2f3a4b5c", 1)
+	if err != nil {
+		t.Fatalf("error generating admin token %s", err)
+	}
+
+	testCases := []test.APITestCase{
+		{
+			Name:    "v1 put advertiser config without admin token",
+			Route:   "http://localhost:9000/v1/admin/advertiser/" + advertiserID + "/config",
+			Method:  http.MethodPut,
+			Status:  http.StatusUnauthorized,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: `{"portals":[{"portal":"ZAP","enabled":true}]}`,
+			Body:    `{"code":"GEN003","error":"Unauthorized"}`,
+		},
+		{
+			Name:    "v1 put invalid advertiser config",
+			Route:   "http://localhost:9000/v1/admin/advertiser/" + advertiserID + "/config",
+			Method:  http.MethodPut,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + adminToken}},
+			Payload: `{"portals":[{"portal":"ZAP","enabled":true},{"portal":"ZAP"}]}`,
+			Body:    `{"code":"VLD001","error":"portals: portals must be unique."}`,
+		},
+		{
+			Name:    "v1 put advertiser config",
+			Route:   "http://localhost:9000/v1/admin/advertiser/" + advertiserID + "/config",
+			Method:  http.MethodPut,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + adminToken}},
+			Payload: `{"portals":[{"portal":"ZAP","enabled":true,"contactEmail":"leads@mailinator.com"}]}`,
+			Body:    `{"advertiserId":"` + advertiserID + `","portals":[{"portal":"ZAP","enabled":true,"contactEmail":"leads@mailinator.com"}]}`,
+		},
+		{
+			Name:    "v1 admin get advertiser config",
+			Route:   "http://localhost:9000/v1/admin/advertiser/" + advertiserID + "/config",
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + adminToken}},
+			Body:    `{"advertiserId":"` + advertiserID + `","portals":[{"portal":"ZAP","enabled":true,"contactEmail":"leads@mailinator.com"}]}`,
+		},
+		{
+			Name:    "v1 get own advertiser config",
+			Route:   "http://localhost:9000/v1/advertiser/config",
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"advertiserId":"` + advertiserID + `","portals":[{"portal":"ZAP","enabled":true,"contactEmail":"leads@mailinator.com"}]}`,
+		},
+		{
+			Name:    "v1 admin get missing advertiser config",
+			Route:   "http://localhost:9000/v1/admin/advertiser/0b0a8a5e-5a3a-4b68-9f53-4e1a8d1c2f10/config",
+			Method:  http.MethodGet,
+			Status:  http.StatusNotFound,
+			Headers: http.Header{"Authorization": {"Bearer " + adminToken}},
+			Body:    `{"code":"GEN005","error":"resource not found"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, tc.Run)
+	}
+}
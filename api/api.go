@@ -2,15 +2,21 @@
 package api
 
 import (
+	"context"
+	"errors"
+	advertiser "go-boilerplate/api/advertiser/v1"
 	comment "go-boilerplate/api/comment/v1"
 
 	"go-boilerplate/api/healthcheck"
 
 	"go-boilerplate/common"
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/metrics"
 	"go-boilerplate/common/response"
+	advertiserFacade "go-boilerplate/facade/advertiser"
+	ratelimitFacade "go-boilerplate/facade/ratelimit"
 	"net/http"
 	"net/http/pprof"
-	"strings"
 	"sync/atomic"
 	"time"
 
@@ -27,6 +33,9 @@ import (
 // @contact.name Esterfano Lopes
 // @contact.url https://github.com/EsterfanoLopes
 // @contact.email esterfano.lopes@gmail.com
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 
 var sentryHandler = sentryhttp.New(sentryhttp.Options{
 	Repanic: true,
@@ -50,6 +59,13 @@ var corsHandler = cors.New(cors.Options{
 	MaxAge: 2592000, // 1 month
 })
 
+var (
+	shutdownDelay   = time.Duration(common.Config.GetInt64("httpServerShutdownDelaySeconds")) * time.Second
+	shutdownTimeout = time.Duration(common.Config.GetInt64("httpServerShutdownTimeoutSeconds")) * time.Second
+
+	advertiserConfigRefreshInterval = time.Duration(common.Config.GetInt64("advertiserConfigRefreshIntervalSeconds")) * time.Second
+)
+
 var apiReady = int32(0)
 
 func apiIsReady() {
@@ -60,15 +76,18 @@ func isAPIReady() bool {
 	return atomic.LoadInt32(&apiReady) == 1
 }
 
-// Setup configures api routes
-func Setup() {
+// Setup configures api routes and serves them until the given context is done, then shuts the server down gracefully
+func Setup(ctx context.Context) error {
 	if isAPIReady() {
-		return
+		return nil
 	}
 
-	r := mux.NewRouter(mux.WithServiceName("go-boilerplate-mux"), mux.WithIgnoreRequest(func(r *http.Request) bool {
-		return strings.HasPrefix(r.URL.Path, "/healthcheck")
-	}))
+	err := auth.Setup()
+	if err != nil {
+		return err
+	}
+
+	r := mux.NewRouter(mux.WithServiceName("go-boilerplate-mux"), mux.WithIgnoreRequest(ignoredRequest))
 
 	r.Handle("/healthcheck/status", handler{
 		handler: healthcheck.SimpleHandler,
@@ -78,19 +97,64 @@ func Setup() {
 		handler: healthcheck.CompleteHandler,
 	}.build()).Methods(http.MethodGet)
 
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
 	setupCommentRoutes(r)
+	setupAdvertiserRoutes(r)
+	r.Use(routeHandler)
+
+	rateLimitIdle, err := setupRateLimits()
+	if err != nil {
+		return err
+	}
+
+	go advertiserFacade.Get().Watch(ctx, advertiserConfigRefreshInterval)
+	go ratelimitFacade.Get().Watch(ctx, rateLimitPurgeInterval, rateLimitIdle)
 
 	srv := &http.Server{
 		ReadTimeout:  time.Duration(common.Config.GetInt64("httpServerReadTimeoutSeconds")) * time.Second,
 		WriteTimeout: time.Duration(common.Config.GetInt64("httpServerWriteTimeoutSeconds")) * time.Second,
 		Addr:         ":9000",
-		Handler:      handlers.CompressHandler(r),
+		Handler:      handlers.CompressHandler(requestHandler(r)),
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
 	common.Logger.Info("server is ready at http://localhost:9000")
 
 	apiIsReady()
 
-	common.Logger.Fatal(srv.ListenAndServe())
+	select {
+	case err := <-serverErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	return shutdown(srv)
+}
+
+// shutdown fails healthchecks first, waits for load balancers to stop routing traffic
+// and then drains in-flight requests within the shutdown timeout
+func shutdown(srv *http.Server) error {
+	common.Logger.Info("shutting down server")
+	healthcheck.ShuttingDown()
+	time.Sleep(shutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		return err
+	}
+	common.Logger.Info("server stopped")
+
+	return nil
 }
 
 func setupSwagger(r *mux.Router) {
@@ -117,43 +181,177 @@ func setupDebugRoutes(r *mux.Router) {
 
 func setupCommentRoutes(r *mux.Router) {
 	r.Handle("/v1/comment", handler{
-		handler: comment.CommentPostHandler,
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentPostHandler,
+	}.build()).Methods(http.MethodPost)
+
+	r.Handle("/v1/comment/bulk", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentBulkPostHandler,
 	}.build()).Methods(http.MethodPost)
 
 	r.Handle("/v1/comment/{id:[0-9]+}", handler{
-		handler: comment.CommentPutHandler,
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentPutHandler,
 	}.build()).Methods(http.MethodPut)
 
 	r.Handle("/v1/comment/{id:[0-9]+}", handler{
-		handler: comment.CommentGetHandler,
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentGetHandler,
 	}.build()).Methods(http.MethodGet)
 
 	r.Handle("/v1/comment", handler{
-		handler: comment.CommentsGetHandler,
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentsGetHandler,
+	}.build()).Methods(http.MethodGet)
+
+	r.Handle("/v1/comment/search", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentSearchGetHandler,
 	}.build()).Methods(http.MethodGet)
 
 	r.Handle("/v1/comment/{id:[0-9]+}", handler{
-		handler: comment.CommentDeleteHandler,
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentDeleteHandler,
 	}.build()).Methods(http.MethodDelete)
+
+	r.Handle("/v1/comment/{id:[0-9]+}/restore", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentRestorePostHandler,
+	}.build()).Methods(http.MethodPost)
+
+	r.Handle("/v1/comment/{id:[0-9]+}/replies", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentRepliesGetHandler,
+	}.build()).Methods(http.MethodGet)
+
+	r.Handle("/v1/comment/{id:[0-9]+}/attachments", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentAttachmentPostHandler,
+	}.build()).Methods(http.MethodPost)
+
+	r.Handle("/v1/comment/{id:[0-9]+}/attachments", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentAttachmentsGetHandler,
+	}.build()).Methods(http.MethodGet)
+
+	r.Handle("/v1/attachment/{id:[0-9]+}", handler{
+		rateLimit: true,
+		handler:   comment.AttachmentGetHandler,
+	}.build()).Methods(http.MethodGet)
+
+	r.Handle("/v1/comment/{id:[0-9]+}/revisions", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   comment.CommentRevisionsGetHandler,
+	}.build()).Methods(http.MethodGet)
+}
+
+func setupAdvertiserRoutes(r *mux.Router) {
+	r.Handle("/v1/advertiser/config", handler{
+		auth:      true,
+		rateLimit: true,
+		handler:   advertiser.AdvertiserConfigGetHandler,
+	}.build()).Methods(http.MethodGet)
+
+	r.Handle("/v1/admin/advertiser/{id}/config", handler{
+		admin:     true,
+		rateLimit: true,
+		handler:   advertiser.AdminAdvertiserConfigGetHandler,
+	}.build()).Methods(http.MethodGet)
+
+	r.Handle("/v1/admin/advertiser/{id}/config", handler{
+		admin:     true,
+		rateLimit: true,
+		handler:   advertiser.AdminAdvertiserConfigPutHandler,
+	}.build()).Methods(http.MethodPut)
+
+	r.Handle("/v1/admin/advertiser/config/versions/{version:[0-9]+}", handler{
+		admin:     true,
+		rateLimit: true,
+		handler:   advertiser.AdminAdvertiserConfigVersionGetHandler,
+	}.build()).Methods(http.MethodGet)
 }
 
 type handler struct {
-	cors    bool
-	handler http.HandlerFunc
+	cors      bool
+	auth      bool
+	admin     bool
+	rateLimit bool
+	handler   http.HandlerFunc
 }
 
 func (o handler) build() http.Handler {
-	h := errorHandler(o.handler)
+	var h http.Handler = o.handler
+	if o.auth {
+		h = authHandler(h)
+	}
+	if o.admin {
+		h = adminHandler(h)
+	}
+	// rate limited before authentication, so unauthenticated requests are limited too
+	if o.rateLimit {
+		h = rateLimitHandler(h, o.principal)
+	}
+	h = errorHandler(h)
 	if o.cors {
 		h = corsHandler.Handler(h)
 	}
 	return h
 }
 
+// principal of the request by the authentication of the handler, if any
+func (o handler) principal(r *http.Request) (auth.Principal, bool) {
+	switch {
+	case o.admin:
+		principal, err := auth.FromAdminRequest(r)
+		return principal, err == nil
+	case o.auth:
+		principal, err := auth.FromRequest(r)
+		return principal, err == nil
+	}
+	return auth.Principal{}, false
+}
+
 func errorHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer handleUnexpectedError(w, r)
-		sentryHandler.Handle(h).ServeHTTP(w, r)
+		sentryHandler.Handle(correlationHandler(h)).ServeHTTP(w, r)
+	})
+}
+
+func authHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.FromRequest(r)
+		if err != nil {
+			response.WriteUnauthorizedError(w)
+			return
+		}
+		accessLogFrom(r.Context()).principal = principal.AccountID
+		h.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func adminHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.FromAdminRequest(r)
+		if err != nil {
+			response.WriteUnauthorizedError(w)
+			return
+		}
+		accessLogFrom(r.Context()).principal = principal.AccountID
+		h.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
 	})
 }
 
@@ -165,6 +363,6 @@ func handleUnexpectedError(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		response.WriteServerError(w, casted, "unexpected error")
+		response.WriteServerError(w, r, casted, "unexpected error")
 	}
 }
@@ -1,10 +1,14 @@
 package api_test
 
 import (
+	"context"
 	"fmt"
 	"go-boilerplate/api"
 	"go-boilerplate/repository"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,7 +19,84 @@ func TestMain(m *testing.M) {
 		fmt.Printf("error starting api tests %s \n", err)
 		os.Exit(-1)
 	}
-	go api.Setup()
+	go api.Setup(context.Background())
 	time.Sleep(1 * time.Second)
 	os.Exit(m.Run())
 }
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		expected  func(string) bool
+	}{
+		{
+			name:      "request id accepted from caller",
+			requestID: "caller-request-1",
+			expected:  func(id string) bool { return id == "caller-request-1" },
+		},
+		{
+			name:     "request id generated",
+			expected: func(id string) bool { return len(id) == 36 },
+		},
+		{
+			name:      "invalid request id replaced",
+			requestID: "invalid request id",
+			expected:  func(id string) bool { return len(id) == 36 },
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://localhost:9000/healthcheck/status", nil)
+			if err != nil {
+				t.Errorf("error creating request %s", err)
+				return
+			}
+			if tc.requestID != "" {
+				req.Header.Set("X-Request-ID", tc.requestID)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Errorf("error calling api %s", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			if requestID := resp.Header.Get("X-Request-ID"); !tc.expected(requestID) {
+				t.Errorf("unexpected request id %s", requestID)
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	_, err := http.Get("http://localhost:9000/v1/comment/1")
+	if err != nil {
+		t.Errorf("error calling api %s", err)
+		return
+	}
+
+	resp, err := http.Get("http://localhost:9000/metrics")
+	if err != nil {
+		t.Errorf("error calling metrics %s", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("error reading metrics %s", err)
+		return
+	}
+
+	for _, expected := range []string{
+		`http_server_requests_total{method="GET",route="/v1/comment/{id:[0-9]+}",status="401"}`,
+		`http_server_request_duration_seconds_count{method="GET",route="/v1/comment/{id:[0-9]+}",status="401"}`,
+		`go_sql_in_use_connections`,
+		`go_sql_wait_duration_seconds_total`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("metric %s not found", expected)
+		}
+	}
+}
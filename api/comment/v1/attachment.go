@@ -0,0 +1,30 @@
+package v1
+
+import (
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/common/auth"
+	"go-boilerplate/domain/comment"
+	"net/url"
+)
+
+var baseURL = common.Config.Get("baseUrl")
+
+// AttachmentUpload is an attachment just created, waiting for its content to be uploaded
+type AttachmentUpload struct {
+	ID int `json:"id"`
+	// UploadURL is a presigned URL accepting a PUT of the attachment content, with the declared content type and size
+	UploadURL   string `json:"uploadUrl"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+// downloadURL of an attachment of the given comment, granting access to it without authentication through a media upload
+// token of the comment account and advertiser
+func downloadURL(cmt comment.Comment, att comment.Attachment) (string, error) {
+	token, err := auth.GenerateMediaToken(cmt.AccountID, cmt.AdvertiserID)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/v1/attachment/%d?token=%s", baseURL, att.ID, url.QueryEscape(token)), nil
+}
@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/response"
+	"net/http"
+	"strconv"
+)
+
+// authorize returns the request principal when it can act on resources of the given advertiser and account,
+// writing the proper error response when it can't
+func authorize(w http.ResponseWriter, r *http.Request, advertiserID, accountID string) (auth.Principal, bool) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.WriteUnauthorizedError(w)
+		return auth.Principal{}, false
+	}
+	if !principal.CanAccess(advertiserID, accountID) {
+		response.WriteForbiddenError(w)
+		return auth.Principal{}, false
+	}
+	return principal, true
+}
+
+// parseIncludeDeleted parses the flag to include soft deleted comments from the request query
+func parseIncludeDeleted(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("includeDeleted")
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
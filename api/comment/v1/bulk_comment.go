@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/response"
+	"go-boilerplate/domain/comment"
+	commentFacade "go-boilerplate/facade/comment"
+	"net/http"
+)
+
+// CommentBulkPostHandler handle bulk requests creating, updating and deleting comments, with the result of each operation in request order.
+// In ALL_OR_NOTHING mode the first failed operation fails the request, while in PARTIAL mode failed operations only fail their results
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param bulk body comment.Bulk true "payload"
+// @Success 200 {object} response.Bulk
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When some comment belongs to another advertiser or account in ALL_OR_NOTHING mode"
+// @Failure 404 {object} response.Error "When some comment was not found in ALL_OR_NOTHING mode"
+// @Failure 412 {object} response.Error "When some comment changed since the given version in ALL_OR_NOTHING mode"
+// @Failure 422 {object} response.Error "When some parent comment is missing, belongs to another advertiser or listing or is nested too deep in ALL_OR_NOTHING mode"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/bulk [post]
+func CommentBulkPostHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	body := comment.Bulk{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.WriteUnprocessableEntity(w, err)
+		return
+	}
+	if err := body.Validate(); err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		response.WriteUnauthorizedError(w)
+		return
+	}
+
+	items := make([]response.BulkItem, len(body.Operations))
+	// valid operations and their indexes in the request
+	ops := []comment.BulkOperation{}
+	indexes := []int{}
+	for i, op := range body.Operations {
+		if err := op.Validate(); err != nil {
+			if body.Mode == comment.BulkAllOrNothing {
+				response.WriteValidationError(w, fmt.Errorf("operation %d: %w", i, err))
+				return
+			}
+			items[i] = response.BulkItemValidationError(err)
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	results, err := commentFacade.Get().Bulk(r.Context(), body.Mode, ops, principal.AccountID, principal.CanAccess)
+	if err != nil {
+		response.WriteError(w, r, err, "error executing bulk comment operations")
+		return
+	}
+	for j, result := range results {
+		if result.Err != nil {
+			items[indexes[j]] = response.BulkItemError(r, result.Err, "error executing bulk comment operation")
+			continue
+		}
+
+		status := http.StatusOK
+		if ops[j].Action == comment.BulkCreate {
+			status = http.StatusCreated
+		}
+		items[indexes[j]] = response.BulkItem{
+			Status: status,
+			ID:     result.ID,
+		}
+	}
+
+	response.Write(w, r, response.Bulk{
+		Items: items,
+	}, http.StatusOK)
+}
@@ -5,17 +5,29 @@ import (
 	"encoding/json"
 	"go-boilerplate/common/response"
 	"go-boilerplate/domain/comment"
+	"go-boilerplate/domain/idempotency"
 	commentFacade "go-boilerplate/facade/comment"
 	"net/http"
 )
 
-// CommentPostHandler handle comment post requests
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// CommentPostHandler handle comment post requests, those sent along an Idempotency-Key header are inserted once per key.
+// Replays of a key result in the response to the first request, flagged by the Idempotent-Replayed header
 // @Tags Comment
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "key to safely retry the request, up to 255 printable ascii characters"
 // @Param comment body comment.Comment true "payload"
 // @Success 201 {object} response.Success
 // @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 422 {object} response.Error "When the parent comment is missing, belongs to another advertiser or listing or is nested too deep, or the idempotency key was used along another comment"
 // @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
 // @Router /v1/comment [post]
 func CommentPostHandler(w http.ResponseWriter, r *http.Request) {
@@ -30,14 +42,43 @@ func CommentPostHandler(w http.ResponseWriter, r *http.Request) {
 		response.WriteValidationError(w, err)
 		return
 	}
+	key := r.Header.Get(idempotencyKeyHeader)
+	if _, sent := r.Header[idempotencyKeyHeader]; sent {
+		if err := idempotency.ValidateKey(key); err != nil {
+			response.WriteValidationError(w, err)
+			return
+		}
+	}
+	principal, ok := authorize(w, r, body.AdvertiserID, body.AccountID)
+	if !ok {
+		return
+	}
 
-	ID, err := commentFacade.Get().Insert(body)
+	if key == "" {
+		ID, err := commentFacade.Get().Insert(r.Context(), body)
+		if err != nil {
+			response.WriteError(w, r, err, "error inserting comment")
+			return
+		}
+
+		response.Write(w, r, response.Success{
+			ID: ID,
+		}, http.StatusCreated)
+		return
+	}
+
+	scope := commentFacade.IdempotencyScope(principal.AdvertiserID, principal.AccountID)
+	resp, replayed, err := commentFacade.Get().InsertIdempotent(r.Context(), body, scope, key, func(ID int) (idempotency.Response, error) {
+		bytes, err := json.Marshal(response.Success{ID: ID})
+		return idempotency.Response{Status: http.StatusCreated, Body: bytes}, err
+	})
 	if err != nil {
 		response.WriteError(w, r, err, "error inserting comment")
 		return
 	}
+	if replayed {
+		w.Header().Set(idempotentReplayedHeader, "true")
+	}
 
-	response.Write(w, response.Success{
-		ID: ID,
-	}, http.StatusCreated)
+	response.Write(w, r, json.RawMessage(resp.Body), resp.Status)
 }
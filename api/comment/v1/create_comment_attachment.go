@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"encoding/json"
+	"go-boilerplate/common/response"
+	"go-boilerplate/domain/comment"
+	commentFacade "go-boilerplate/facade/comment"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CommentAttachmentPostHandler handle comment attachment post requests, the attachment content must then be uploaded to its upload URL
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "id" Format(int)
+// @Param attachment body comment.Attachment true "payload"
+// @Success 201 {object} v1.AttachmentUpload
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/{id}/attachments [post]
+func CommentAttachmentPostHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	body := comment.Attachment{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		response.WriteUnprocessableEntity(w, err)
+		return
+	}
+	if err := body.Validate(); err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	current, err := commentFacade.Get().FindByID(r.Context(), ID, false)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	principal, ok := authorize(w, r, current.AdvertiserID, current.AccountID)
+	if !ok {
+		return
+	}
+
+	body.CommentID = ID
+	body.CreatedBy = principal.AccountID
+	attachmentID, uploadURL, err := commentFacade.Get().InsertAttachment(r.Context(), body)
+	if err != nil {
+		response.WriteError(w, r, err, "error inserting comment attachment")
+		return
+	}
+	body.ID = attachmentID
+
+	download, err := downloadURL(current, body)
+	if err != nil {
+		response.WriteServerError(w, r, err, "error generating attachment download url")
+		return
+	}
+
+	response.Write(w, r, AttachmentUpload{
+		ID:          attachmentID,
+		UploadURL:   uploadURL,
+		DownloadURL: download,
+	}, http.StatusCreated)
+}
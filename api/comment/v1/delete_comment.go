@@ -13,9 +13,15 @@ import (
 // @Tags Comment
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "id" Format(int)
+// @Param If-Match header string false "ETag of the comment version being deleted"
 // @Success 204 {string} string "Success"
 // @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 412 {object} response.Error "When the comment changed since the given ETag"
 // @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
 // @Router /v1/comment/{id} [delete]
 func CommentDeleteHandler(w http.ResponseWriter, r *http.Request) {
@@ -27,11 +33,27 @@ func CommentDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = commentFacade.Get().Delete(ID)
+	version, err := parseIfMatch(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	current, err := commentFacade.Get().FindByID(r.Context(), ID, false)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	principal, ok := authorize(w, r, current.AdvertiserID, current.AccountID)
+	if !ok {
+		return
+	}
+
+	err = commentFacade.Get().Delete(r.Context(), ID, version, principal.AccountID)
 	if err != nil {
 		response.WriteError(w, r, err, "error deleting comment")
 		return
 	}
 
-	response.Write(w, nil, http.StatusNoContent)
+	response.Write(w, r, nil, http.StatusNoContent)
 }
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"go-boilerplate/domain/comment"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be a single comment ETag")
+
+// etag of the given comment, based on its version
+func etag(cmt comment.Comment) string {
+	return fmt.Sprintf(`"%d"`, cmt.Version)
+}
+
+// parseIfMatch returns the comment version expected by the If-Match header, zero when any version is accepted
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || version < 1 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, errInvalidIfMatch
+	}
+
+	return version, nil
+}
+
+// noneMatch tells if the given ETag doesn't match any of the If-None-Match header values
+func noneMatch(r *http.Request, tag string) bool {
+	value := r.Header.Get("If-None-Match")
+	if value == "" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(value, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/response"
+	commentFacade "go-boilerplate/facade/comment"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AttachmentGetHandler handle attachment download requests authenticated by a media upload token,
+// redirecting to a presigned URL of the attachment content
+// @Tags Comment
+// @Param id path int true "id" Format(int)
+// @Param token query string true "Media upload token of the attachment download URL"
+// @Success 302 {string} string "Redirects to the attachment content"
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid media upload token"
+// @Failure 403 {object} response.Error "When the attachment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the attachment or its comment was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to generate the content URL"
+// @Router /v1/attachment/{id} [get]
+func AttachmentGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	// the token grants access to the attachments of its account and advertiser only, checked once its comment is found
+	principal, err := auth.ParseMediaToken(r.URL.Query().Get("token"))
+	if err != nil {
+		response.WriteUnauthorizedError(w)
+		return
+	}
+
+	att, err := commentFacade.Get().FindAttachmentByID(r.Context(), ID)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding attachment by id")
+		return
+	}
+	current, err := commentFacade.Get().FindByID(r.Context(), att.CommentID, false)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	if !principal.CanAccess(current.AdvertiserID, current.AccountID) {
+		response.WriteForbiddenError(w)
+		return
+	}
+
+	location, err := commentFacade.Get().AttachmentDownloadURL(r.Context(), att)
+	if err != nil {
+		response.WriteServerError(w, r, err, "error generating attachment content url")
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusFound)
+}
@@ -13,9 +13,16 @@ import (
 // @Tags Comment
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "id" Format(int)
+// @Param includeDeleted query bool false "includeDeleted"
+// @Param If-None-Match header string false "ETag of a previously fetched version"
 // @Success 200 {object} comment.Comment
+// @Success 304 {string} string "When the comment didn't change since the given ETag"
 // @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
 // @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
 // @Router /v1/comment/{id} [get]
 func CommentGetHandler(w http.ResponseWriter, r *http.Request) {
@@ -27,11 +34,27 @@ func CommentGetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := commentFacade.Get().FindByID(ID)
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	result, err := commentFacade.Get().FindByID(r.Context(), ID, includeDeleted)
 	if err != nil {
 		response.WriteError(w, r, err, "error finding comment by id")
 		return
 	}
+	if _, ok := authorize(w, r, result.AdvertiserID, result.AccountID); !ok {
+		return
+	}
+
+	tag := etag(result)
+	w.Header().Set("ETag", tag)
+	if !noneMatch(r, tag) {
+		response.Write(w, r, nil, http.StatusNotModified)
+		return
+	}
 
-	response.Write(w, result, http.StatusOK)
+	response.Write(w, r, result, http.StatusOK)
 }
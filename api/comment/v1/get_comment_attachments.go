@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/common/response"
+	commentFacade "go-boilerplate/facade/comment"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CommentAttachmentsGetHandler handle comment attachments get requests
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "id" Format(int)
+// @Param from query int false "from" Format(int)
+// @Param size query int false "size" Format(int)
+// @Success 200 {object} pagination.Response{results=[]comment.Attachment}
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/{id}/attachments [get]
+func CommentAttachmentsGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	p, err := pagination.FromRequest(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	current, err := commentFacade.Get().FindByID(r.Context(), ID, false)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	if _, ok := authorize(w, r, current.AdvertiserID, current.AccountID); !ok {
+		return
+	}
+
+	results, count, err := commentFacade.Get().FindAttachments(r.Context(), ID, p)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment attachments")
+		return
+	}
+	for i := range results {
+		results[i].DownloadURL, err = downloadURL(current, results[i])
+		if err != nil {
+			response.WriteServerError(w, r, err, "error generating attachment download url")
+			return
+		}
+	}
+
+	response.Write(w, r, p.GetResponse(count, results), http.StatusOK)
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/common/response"
+	commentFacade "go-boilerplate/facade/comment"
+	commentRepository "go-boilerplate/repository/comment"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CommentRepliesGetHandler handle comment replies get requests, soft deleted replies that still have replies are returned as tombstones
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "id" Format(int)
+// @Param includeDeleted query bool false "includeDeleted"
+// @Param from query int false "from" Format(int)
+// @Param size query int false "size" Format(int)
+// @Param cursor query string false "nextCursor or prevCursor of a previous page, replaces from"
+// @Param skipCount query bool false "skipCount"
+// @Success 200 {object} pagination.Response{results=[]comment.Comment}
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/{id}/replies [get]
+func CommentRepliesGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	p, err := pagination.FromRequest(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	parent, err := commentFacade.Get().FindByID(r.Context(), ID, true)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	if _, ok := authorize(w, r, parent.AdvertiserID, parent.AccountID); !ok {
+		return
+	}
+
+	// replies of other accounts of the advertiser are part of the thread, as they are in its reply count
+	results, count, err := commentFacade.Get().Find(r.Context(), commentRepository.Query{
+		AdvertiserID:   parent.AdvertiserID,
+		ParentID:       ID,
+		IncludeDeleted: includeDeleted,
+	}, p)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment replies")
+		return
+	}
+
+	response.Write(w, r, p.GetKeysetResponse(count, results, keysOf(results)), http.StatusOK)
+}
@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"errors"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/common/response"
+	commentFacade "go-boilerplate/facade/comment"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+var errRevisionsWithCursor = errors.New("cursor: can't be used with revisions, they are paged by from and size")
+
+// CommentRevisionsGetHandler handle comment revisions get requests
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "id" Format(int)
+// @Param from query int false "from" Format(int)
+// @Param size query int false "size" Format(int)
+// @Param skipCount query bool false "skipCount"
+// @Success 200 {object} pagination.Response{results=[]comment.Revision}
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/{id}/revisions [get]
+func CommentRevisionsGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	p, err := pagination.FromRequest(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+	if p.HasCursor() {
+		response.WriteValidationError(w, errRevisionsWithCursor)
+		return
+	}
+
+	current, err := commentFacade.Get().FindByID(r.Context(), ID, true)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	if _, ok := authorize(w, r, current.AdvertiserID, current.AccountID); !ok {
+		return
+	}
+
+	results, count, err := commentFacade.Get().FindRevisions(r.Context(), ID, p)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment revisions")
+		return
+	}
+
+	response.Write(w, r, p.GetResponse(count, results), http.StatusOK)
+}
@@ -1,42 +1,133 @@
 package v1
 
 import (
+	"errors"
 	"go-boilerplate/common/pagination"
 	"go-boilerplate/common/response"
+	"go-boilerplate/domain/comment"
 	commentFacade "go-boilerplate/facade/comment"
+	"go-boilerplate/repository"
 	commentRepository "go-boilerplate/repository/comment"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+var errSortWithCursor = errors.New("cursor: can only be used with the default sort")
+
 func parseRequest(r *http.Request) (commentRepository.Query, pagination.Pagination, error) {
 	params := r.URL.Query()
-	advertiserID := params.Get("advertiserId")
-	accountID := params.Get("accountId")
-	listingID := params.Get("listingId")
+	q := commentRepository.Query{
+		AdvertiserID: params.Get("advertiserId"),
+		AccountID:    params.Get("accountId"),
+		ListingID:    params.Get("listingId"),
+		Text:         params.Get("text"),
+	}
+
+	var err error
+	q.IncludeDeleted, err = parseIncludeDeleted(r)
+	if err != nil {
+		return commentRepository.Query{}, pagination.Pagination{}, err
+	}
+
+	for _, value := range params["type"] {
+		for _, v := range strings.Split(value, ",") {
+			tp, err := comment.TypeValueOf(v)
+			if err != nil {
+				return commentRepository.Query{}, pagination.Pagination{}, err
+			}
+			q.Types = append(q.Types, tp)
+		}
+	}
+
+	for param, value := range map[string]*time.Time{
+		"createdFrom": &q.CreatedFrom,
+		"createdTo":   &q.CreatedTo,
+		"updatedFrom": &q.UpdatedFrom,
+		"updatedTo":   &q.UpdatedTo,
+	} {
+		if params.Get(param) == "" {
+			continue
+		}
+		*value, err = time.Parse(time.RFC3339, params.Get(param))
+		if err != nil {
+			return commentRepository.Query{}, pagination.Pagination{}, err
+		}
+	}
+
+	if threadedParameter := params.Get("threaded"); threadedParameter != "" {
+		q.Threaded, err = strconv.ParseBool(threadedParameter)
+		if err != nil {
+			return commentRepository.Query{}, pagination.Pagination{}, err
+		}
+	}
+
+	if updatedParameter := params.Get("updated"); updatedParameter != "" {
+		updated, err := strconv.ParseBool(updatedParameter)
+		if err != nil {
+			return commentRepository.Query{}, pagination.Pagination{}, err
+		}
+		q.Updated = &updated
+	}
+
+	if sortParameter := params.Get("sort"); sortParameter != "" {
+		q.SortField, err = commentRepository.SortFieldValueOf(sortParameter)
+		if err != nil {
+			return commentRepository.Query{}, pagination.Pagination{}, err
+		}
+		q.SortDirection = repository.Desc
+	}
+	orderParameter := params.Get("order")
+	if orderParameter != "" {
+		q.SortDirection, err = repository.OrderDirectionValueOf(orderParameter)
+		if err != nil {
+			return commentRepository.Query{}, pagination.Pagination{}, err
+		}
+	}
 
 	p, err := pagination.FromRequest(r)
 	if err != nil {
 		return commentRepository.Query{}, pagination.Pagination{}, err
 	}
+	if p.HasCursor() && q.SortField != commentRepository.SortNone {
+		return commentRepository.Query{}, pagination.Pagination{}, errSortWithCursor
+	}
+	// without a sort the order is the direction of the pages, so cursors keep working
+	if orderParameter != "" && q.SortField == commentRepository.SortNone && q.SortDirection == repository.Asc {
+		p = p.OldestFirst()
+	}
 
-	return commentRepository.Query{
-		AdvertiserID: advertiserID,
-		AccountID:    accountID,
-		ListingID:    listingID,
-	}, p, nil
+	return q, p, nil
 }
 
 // CommentsGetHandler handle comments get requests
 // @Tags Comment
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param listingId query int false "listingId"
 // @Param accountId query int false "accountId"
 // @Param advertiserId query int false "advertiserId"
+// @Param type query []string false "type" collectionFormat(multi)
+// @Param createdFrom query string false "createdFrom" Format(date-time)
+// @Param createdTo query string false "createdTo" Format(date-time)
+// @Param updatedFrom query string false "updatedFrom" Format(date-time)
+// @Param updatedTo query string false "updatedTo" Format(date-time)
+// @Param updated query bool false "updated"
+// @Param text query string false "Case insensitive substring of the description"
+// @Param sort query string false "sort" Enums(createdAt, updatedAt, type)
+// @Param order query string false "order, desc by default, orders the pages by createdAt when sort is not set" Enums(asc, desc)
+// @Param threaded query bool false "Only root comments, with their reply counts"
+// @Param includeDeleted query bool false "includeDeleted"
 // @Param from query int false "from" Format(int)
 // @Param size query int false "size" Format(int)
+// @Param cursor query string false "nextCursor or prevCursor of a previous page, replaces from, only without sort"
+// @Param skipCount query bool false "skipCount"
 // @Success 200 {object} pagination.Response{results=[]comment.Comment}
 // @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the query targets to another advertiser or account"
 // @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
 // @Router /v1/comment [get]
 func CommentsGetHandler(w http.ResponseWriter, r *http.Request) {
@@ -51,12 +142,29 @@ func CommentsGetHandler(w http.ResponseWriter, r *http.Request) {
 		response.WriteValidationError(w, err)
 		return
 	}
+	if _, ok := authorize(w, r, q.AdvertiserID, q.AccountID); !ok {
+		return
+	}
 
-	results, count, err := commentFacade.Get().Find(q, p)
+	results, count, err := commentFacade.Get().Find(r.Context(), q, p)
 	if err != nil {
 		response.WriteError(w, r, err, "error finding comments")
 		return
 	}
 
-	response.Write(w, p.GetResponse(count, results), http.StatusOK)
+	// cursors are positions in the creation order, pages of other sorts have none
+	if q.SortField != commentRepository.SortNone {
+		response.Write(w, r, p.GetResponse(count, results), http.StatusOK)
+		return
+	}
+	response.Write(w, r, p.GetKeysetResponse(count, results, keysOf(results)), http.StatusOK)
+}
+
+func keysOf(results []comment.Comment) []pagination.Key {
+	keys := make([]pagination.Key, len(results))
+	for i, result := range results {
+		keys[i] = pagination.Key{CreatedAt: result.CreatedAt, ID: result.ID}
+	}
+
+	return keys
 }
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"go-boilerplate/common/response"
+	commentFacade "go-boilerplate/facade/comment"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CommentRestorePostHandler handle soft deleted comment restore requests
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "id" Format(int)
+// @Success 200 {object} response.Success
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/{id}/restore [post]
+func CommentRestorePostHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
+	current, err := commentFacade.Get().FindByID(r.Context(), ID, true)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	principal, ok := authorize(w, r, current.AdvertiserID, current.AccountID)
+	if !ok {
+		return
+	}
+
+	err = commentFacade.Get().Restore(r.Context(), ID, principal.AccountID)
+	if err != nil {
+		response.WriteError(w, r, err, "error restoring comment")
+		return
+	}
+
+	response.Write(w, r, response.Success{
+		ID: ID,
+	}, http.StatusOK)
+}
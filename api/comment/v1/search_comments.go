@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"errors"
+	"go-boilerplate/common/response"
+	commentFacade "go-boilerplate/facade/comment"
+	commentRepository "go-boilerplate/repository/comment"
+	"net/http"
+)
+
+var (
+	errMissingSearch     = errors.New("q: cannot be blank")
+	errSearchWithCursor  = errors.New("cursor: can't be used with search, results are ranked by relevance")
+	errSearchWithSorting = errors.New("sort: can't be used with search, results are ranked by relevance")
+)
+
+// CommentSearchGetHandler handle comments full-text search requests
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Full-text search terms, supporting quoted phrases, or and -exclusions"
+// @Param listingId query int false "listingId"
+// @Param accountId query int false "accountId"
+// @Param advertiserId query int false "advertiserId"
+// @Param type query []string false "type" collectionFormat(multi)
+// @Param createdFrom query string false "createdFrom" Format(date-time)
+// @Param createdTo query string false "createdTo" Format(date-time)
+// @Param updatedFrom query string false "updatedFrom" Format(date-time)
+// @Param updatedTo query string false "updatedTo" Format(date-time)
+// @Param updated query bool false "updated"
+// @Param includeDeleted query bool false "includeDeleted"
+// @Param from query int false "from" Format(int)
+// @Param size query int false "size" Format(int)
+// @Param skipCount query bool false "skipCount"
+// @Success 200 {object} pagination.Response{results=[]comment.SearchResult}
+// @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the query targets to another advertiser or account"
+// @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
+// @Router /v1/comment/search [get]
+func CommentSearchGetHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q, p, err := parseRequest(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+	q.Search = r.URL.Query().Get("q")
+	switch {
+	case q.Search == "":
+		response.WriteValidationError(w, errMissingSearch)
+		return
+	case p.HasCursor():
+		response.WriteValidationError(w, errSearchWithCursor)
+		return
+	case q.SortField != commentRepository.SortNone:
+		response.WriteValidationError(w, errSearchWithSorting)
+		return
+	}
+	if err := q.Validate(); err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+	if _, ok := authorize(w, r, q.AdvertiserID, q.AccountID); !ok {
+		return
+	}
+
+	results, count, err := commentFacade.Get().Search(r.Context(), q, p)
+	if err != nil {
+		response.WriteError(w, r, err, "error searching comments")
+		return
+	}
+
+	response.Write(w, r, p.GetResponse(count, results), http.StatusOK)
+}
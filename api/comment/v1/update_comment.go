@@ -15,10 +15,16 @@ import (
 // @Tags Comment
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "id" Format(int)
 // @Param comment body comment.Comment true "payload"
+// @Param If-Match header string false "ETag of the comment version being updated"
 // @Success 200 {object} response.Success
 // @Failure 400 {object} response.Error "When some value of the request is invalid"
+// @Failure 401 {object} response.Error "When the request has no valid bearer token"
+// @Failure 403 {object} response.Error "When the comment belongs to another advertiser or account"
+// @Failure 404 {object} response.Error "When the comment was not found"
+// @Failure 412 {object} response.Error "When the comment changed since the given ETag"
 // @Failure 500 {object} response.Error "When something was wrong when trying to persist data"
 // @Router /v1/comment/{id} [put]
 func CommentPutHandler(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +36,12 @@ func CommentPutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := parseIfMatch(r)
+	if err != nil {
+		response.WriteValidationError(w, err)
+		return
+	}
+
 	body := comment.Comment{}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		response.WriteUnprocessableEntity(w, err)
@@ -39,15 +51,31 @@ func CommentPutHandler(w http.ResponseWriter, r *http.Request) {
 		response.WriteValidationError(w, err)
 		return
 	}
+	if _, ok := authorize(w, r, body.AdvertiserID, body.AccountID); !ok {
+		return
+	}
+
+	current, err := commentFacade.Get().FindByID(r.Context(), ID, false)
+	if err != nil {
+		response.WriteError(w, r, err, "error finding comment by id")
+		return
+	}
+	principal, ok := authorize(w, r, current.AdvertiserID, current.AccountID)
+	if !ok {
+		return
+	}
 
 	body.ID = ID
-	err = commentFacade.Get().Update(body)
+	body.Version = version
+	updated, err := commentFacade.Get().Update(r.Context(), body, principal.AccountID)
 	if err != nil {
 		response.WriteError(w, r, err, "error updating comment")
 		return
 	}
 
-	response.Write(w, response.Success{
+	// the new ETag lets the next conditional update go without fetching the comment again
+	w.Header().Set("ETag", etag(updated))
+	response.Write(w, r, response.Success{
 		ID: ID,
 	}, http.StatusOK)
 }
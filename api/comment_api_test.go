@@ -3,11 +3,17 @@ package api_test
 import (
 	"fmt"
 	common "go-boilerplate/common"
+	"go-boilerplate/common/auth"
 	"go-boilerplate/test"
 	"net/http"
 	"testing"
 
 	"go-boilerplate/repository"
+	commentRepository "go-boilerplate/repository/comment"
+	"go-boilerplate/repository/comment/revision"
+	"go-boilerplate/repository/idempotency"
+
+	"github.com/brianvoe/gofakeit/v5"
 )
 
 func TestCommentRoutes(t *testing.T) {
@@ -15,6 +21,19 @@ func TestCommentRoutes(t *testing.T) {
 	test.FreezeTime(t, date)
 
 	nextID := repository.GetNextID(t, "comment")
+	nextRevisionID := repository.GetNextID(t, "comment_revision")
+	t.Cleanup(func() {
+		revision.DeleteTestData(t, nextID)
+	})
+
+	token, err := auth.GenerateToken("34178e2a-b9be-48ef-bfb4-3973747ae257", "77e04ae6-c3dc-4a60-8b52-d1fc35d42098", 1)
+	if err != nil {
+		t.Fatalf("error generating access token %s", err)
+	}
+	otherToken, err := auth.GenerateToken("34178e2a-b9be-48ef-bfb4-3973747ae257", "0b0a8a5e-5a3a-4b68-9f53-4e1a8d1c2f10", 1)
+	if err != nil {
+		t.Fatalf("error generating access token %s", err)
+	}
 
 	testCases := []test.APITestCase{
 		{
@@ -22,6 +41,7 @@ func TestCommentRoutes(t *testing.T) {
 			Route:   "http://localhost:9000/v1/comment",
 			Method:  http.MethodPost,
 			Status:  http.StatusCreated,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
 			Payload: `{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "Pessoa foi visitar e ninguém viu, dessa vez não vamos dar vacilo","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}`,
 			Body:    fmt.Sprintf(`{"id":%d}`, nextID),
 		},
@@ -30,34 +50,328 @@ func TestCommentRoutes(t *testing.T) {
 			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
 			Method:  http.MethodPut,
 			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
 			Payload: `{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "A pessoa tentou realizar a visita, mas não obteve atendimento, estarei enviando um presente para ela.","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}`,
 			Body:    fmt.Sprintf(`{"id":%d}`, nextID),
 		},
 		{
-			Name:   "v1 get comment",
-			Route:  fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
-			Method: http.MethodGet,
-			Status: http.StatusOK,
+			Name:    "v1 put comment with stale etag",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodPut,
+			Status:  http.StatusPreconditionFailed,
+			Headers: http.Header{"Authorization": {"Bearer " + token}, "If-Match": {`"1"`}},
+			Payload: `{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "Nota desatualizada","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}`,
+			Body:    `{"code":"GEN007","error":"resource version mismatch"}`,
+		},
+		{
+			Name:    "v1 get comment not modified",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusNotModified,
+			Headers: http.Header{"Authorization": {"Bearer " + token}, "If-None-Match": {`"2"`}},
+		},
+		{
+			Name:    "v1 get comment",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
 			Body: fmt.Sprintf(
 				`{"id":%d,"type":"SCHEDULE","description":"A pessoa tentou realizar a visita, mas não obteve atendimento, estarei enviando um presente para ela.","advertiserId":"77e04ae6-c3dc-4a60-8b52-d1fc35d42098","accountId":"34178e2a-b9be-48ef-bfb4-3973747ae257","listingId":"2323232323","updated":true,"owner":{"name":"José Silva","email":"jose.silva@mailinator.com","accountId":"1071a242-5d3f-45e5-9a7a-b64b9ab68e98"},"createdAt":"2021-01-06T20:35:00-03:00","updatedAt":"2021-01-06T20:35:00-03:00"}`,
 				nextID,
 			),
 		},
 		{
-			Name:   "v1 get comments",
-			Route:  "http://localhost:9000/v1/comment?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&listingId=2323232323",
-			Method: http.MethodGet,
-			Status: http.StatusOK,
+			Name:    "v1 get comments",
+			Route:   "http://localhost:9000/v1/comment?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&listingId=2323232323",
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
 			Body: fmt.Sprintf(
 				`{"total":1,"results":[{"id":%d,"type":"SCHEDULE","description":"A pessoa tentou realizar a visita, mas não obteve atendimento, estarei enviando um presente para ela.","advertiserId":"77e04ae6-c3dc-4a60-8b52-d1fc35d42098","accountId":"34178e2a-b9be-48ef-bfb4-3973747ae257","listingId":"2323232323","updated":true,"owner":{"name":"José Silva","email":"jose.silva@mailinator.com","accountId":"1071a242-5d3f-45e5-9a7a-b64b9ab68e98"},"createdAt":"2021-01-06T20:35:00-03:00","updatedAt":"2021-01-06T20:35:00-03:00"}]}`,
 				nextID,
 			),
 		},
 		{
-			Name:   "v1 delete comment",
+			Name:    "v1 get comments oldest first",
+			Route:   "http://localhost:9000/v1/comment?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&listingId=2323232323&order=asc",
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body: fmt.Sprintf(
+				`{"total":1,"results":[{"id":%d,"type":"SCHEDULE","description":"A pessoa tentou realizar a visita, mas não obteve atendimento, estarei enviando um presente para ela.","advertiserId":"77e04ae6-c3dc-4a60-8b52-d1fc35d42098","accountId":"34178e2a-b9be-48ef-bfb4-3973747ae257","listingId":"2323232323","updated":true,"owner":{"name":"José Silva","email":"jose.silva@mailinator.com","accountId":"1071a242-5d3f-45e5-9a7a-b64b9ab68e98"},"createdAt":"2021-01-06T20:35:00-03:00","updatedAt":"2021-01-06T20:35:00-03:00"}]}`,
+				nextID,
+			),
+		},
+		{
+			Name:    "v1 get comments sorted after a cursor",
+			Route:   "http://localhost:9000/v1/comment?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&sort=updatedAt&cursor=eyJjIjoiMjAyMS0wMS0wNlQyMzozNTowMFoiLCJpIjoxfQ",
+			Method:  http.MethodGet,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"code":"VLD001","error":"cursor: can only be used with the default sort"}`,
+		},
+		{
+			Name:    "v1 get comments by type sorted by update",
+			Route:   "http://localhost:9000/v1/comment?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&type=LEAD,CREDIT&sort=updatedAt&order=asc",
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"total":0,"results":[]}`,
+		},
+		{
+			Name:    "v1 get comments with unknown sort",
+			Route:   "http://localhost:9000/v1/comment?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&sort=description",
+			Method:  http.MethodGet,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"code":"VLD001","error":"unknown sort field value description"}`,
+		},
+		{
+			Name:    "v1 search comments",
+			Route:   "http://localhost:9000/v1/comment/search?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257&q=fiador&skipCount=true",
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"results":[]}`,
+		},
+		{
+			Name:    "v1 search comments without terms",
+			Route:   "http://localhost:9000/v1/comment/search?advertiserId=77e04ae6-c3dc-4a60-8b52-d1fc35d42098&accountId=34178e2a-b9be-48ef-bfb4-3973747ae257",
+			Method:  http.MethodGet,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"code":"VLD001","error":"q: cannot be blank"}`,
+		},
+		{
+			Name:    "v1 get comment replies",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d/replies", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"total":0,"results":[]}`,
+		},
+		{
+			Name:    "v1 post comment attachment with unsupported content type",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d/attachments", nextID),
+			Method:  http.MethodPost,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: `{"fileName": "script.sh","contentType": "text/x-shellscript","size": 1024}`,
+			Body:    `{"code":"VLD001","error":"contentType: must be a valid value."}`,
+		},
+		{
+			Name:    "v1 get comment attachments",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d/attachments", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"total":0,"results":[]}`,
+		},
+		{
+			Name:   "v1 get attachment with invalid token",
+			Route:  "http://localhost:9000/v1/attachment/1?token=invalid",
+			Method: http.MethodGet,
+			Status: http.StatusUnauthorized,
+			Body:   `{"code":"GEN003","error":"Unauthorized"}`,
+		},
+		{
+			Name:    "v1 get comment revisions",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d/revisions", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body: fmt.Sprintf(
+				`{"total":1,"results":[{"id":%d,"commentId":%d,"action":"UPDATE","type":"SCHEDULE","description":"Pessoa foi visitar e ninguém viu, dessa vez não vamos dar vacilo","changedBy":"34178e2a-b9be-48ef-bfb4-3973747ae257","createdAt":"2021-01-06T20:35:00-03:00"}]}`,
+				nextRevisionID,
+				nextID,
+			),
+		},
+		{
+			Name:    "v1 get comment revisions after a cursor",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d/revisions?cursor=eyJjIjoiMjAyMS0wMS0wNlQyMzozNTowMFoiLCJpIjoxfQ", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"code":"VLD001","error":"cursor: can't be used with revisions, they are paged by from and size"}`,
+		},
+		{
+			Name:   "v1 get comment without token",
 			Route:  fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
-			Method: http.MethodDelete,
-			Status: http.StatusNoContent,
+			Method: http.MethodGet,
+			Status: http.StatusUnauthorized,
+			Body:   `{"code":"GEN003","error":"Unauthorized"}`,
+		},
+		{
+			Name:    "v1 get comment of another advertiser",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusForbidden,
+			Headers: http.Header{"Authorization": {"Bearer " + otherToken}},
+			Body:    `{"code":"GEN004","error":"Forbidden"}`,
+		},
+		{
+			Name:    "v1 delete comment of another advertiser",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodDelete,
+			Status:  http.StatusForbidden,
+			Headers: http.Header{"Authorization": {"Bearer " + otherToken}},
+			Body:    `{"code":"GEN004","error":"Forbidden"}`,
+		},
+		{
+			Name:    "v1 delete comment",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodDelete,
+			Status:  http.StatusNoContent,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+		},
+		{
+			Name:    "v1 get deleted comment",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID),
+			Method:  http.MethodGet,
+			Status:  http.StatusNotFound,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"code":"GEN005","error":"resource not found"}`,
+		},
+		{
+			Name:    "v1 put missing comment",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID+1000),
+			Method:  http.MethodPut,
+			Status:  http.StatusNotFound,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: `{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "Nota inexistente","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}`,
+			Body:    `{"code":"GEN005","error":"resource not found"}`,
+		},
+		{
+			Name:    "v1 delete missing comment",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d", nextID+1000),
+			Method:  http.MethodDelete,
+			Status:  http.StatusNotFound,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    `{"code":"GEN005","error":"resource not found"}`,
+		},
+		{
+			Name:    "v1 restore comment",
+			Route:   fmt.Sprintf("http://localhost:9000/v1/comment/%d/restore", nextID),
+			Method:  http.MethodPost,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Body:    fmt.Sprintf(`{"id":%d}`, nextID),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, tc.Run)
+	}
+}
+
+func TestCommentPostIdempotency(t *testing.T) {
+	nextID := repository.GetNextID(t, "comment")
+	t.Cleanup(func() {
+		commentRepository.DeleteTestData(t, nextID)
+		idempotency.DeleteTestData(t, "comment:77e04ae6-c3dc-4a60-8b52-d1fc35d42098:34178e2a-b9be-48ef-bfb4-3973747ae257")
+	})
+
+	token, err := auth.GenerateToken("34178e2a-b9be-48ef-bfb4-3973747ae257", "77e04ae6-c3dc-4a60-8b52-d1fc35d42098", 1)
+	if err != nil {
+		t.Fatalf("error generating access token %s", err)
+	}
+	key := gofakeit.UUID()
+	payload := `{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "Cliente pediu retorno amanhã","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}`
+
+	testCases := []test.APITestCase{
+		{
+			Name:    "v1 post comment with idempotency key",
+			Route:   "http://localhost:9000/v1/comment",
+			Method:  http.MethodPost,
+			Status:  http.StatusCreated,
+			Headers: http.Header{"Authorization": {"Bearer " + token}, "Idempotency-Key": {key}},
+			Payload: payload,
+			Body:    fmt.Sprintf(`{"id":%d}`, nextID),
+		},
+		{
+			Name:    "v1 post comment replayed",
+			Route:   "http://localhost:9000/v1/comment",
+			Method:  http.MethodPost,
+			Status:  http.StatusCreated,
+			Headers: http.Header{"Authorization": {"Bearer " + token}, "Idempotency-Key": {key}},
+			Payload: payload,
+			Body:    fmt.Sprintf(`{"id":%d}`, nextID),
+		},
+		{
+			Name:    "v1 post comment reusing idempotency key",
+			Route:   "http://localhost:9000/v1/comment",
+			Method:  http.MethodPost,
+			Status:  http.StatusUnprocessableEntity,
+			Headers: http.Header{"Authorization": {"Bearer " + token}, "Idempotency-Key": {key}},
+			Payload: `{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "Outra nota","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}`,
+			Body:    `{"code":"GEN002","error":"unprocessable request: idempotency key already used by another request"}`,
+		},
+		{
+			Name:    "v1 post comment with invalid idempotency key",
+			Route:   "http://localhost:9000/v1/comment",
+			Method:  http.MethodPost,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}, "Idempotency-Key": {""}},
+			Payload: payload,
+			Body:    `{"code":"VLD001","error":"idempotencyKey: cannot be blank."}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, tc.Run)
+	}
+}
+
+func TestCommentBulk(t *testing.T) {
+	// the id taken by the rolled back all or nothing request isn't reused
+	nextID := repository.GetNextID(t, "comment") + 1
+	t.Cleanup(func() {
+		commentRepository.DeleteTestData(t, nextID)
+	})
+
+	token, err := auth.GenerateToken("34178e2a-b9be-48ef-bfb4-3973747ae257", "77e04ae6-c3dc-4a60-8b52-d1fc35d42098", 1)
+	if err != nil {
+		t.Fatalf("error generating access token %s", err)
+	}
+	create := `{"action":"CREATE","comment":{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","description": "Nota migrada do CRM antigo","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}}`
+	invalid := `{"action":"CREATE","comment":{"accountId": "34178e2a-b9be-48ef-bfb4-3973747ae257","advertiserId": "77e04ae6-c3dc-4a60-8b52-d1fc35d42098","listingId": "2323232323","owner": {"accountId": "1071a242-5d3f-45e5-9a7a-b64b9ab68e98","name": "José Silva","email":"jose.silva@mailinator.com"},"type": "SCHEDULE"}}`
+	missing := `{"action":"DELETE","id":999999999}`
+
+	testCases := []test.APITestCase{
+		{
+			Name:    "v1 bulk comment with an invalid operation in all or nothing mode",
+			Route:   "http://localhost:9000/v1/comment/bulk",
+			Method:  http.MethodPost,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: fmt.Sprintf(`{"mode":"ALL_OR_NOTHING","operations":[%s,%s]}`, create, invalid),
+			Body:    `{"code":"VLD001","error":"operation 1: comment: (description: cannot be blank.)."}`,
+		},
+		{
+			Name:    "v1 bulk comment with a failed operation in all or nothing mode",
+			Route:   "http://localhost:9000/v1/comment/bulk",
+			Method:  http.MethodPost,
+			Status:  http.StatusNotFound,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: fmt.Sprintf(`{"mode":"ALL_OR_NOTHING","operations":[%s,%s]}`, create, missing),
+			Body:    `{"code":"GEN005","error":"operation 1: resource not found"}`,
+		},
+		{
+			Name:    "v1 bulk comment in partial mode",
+			Route:   "http://localhost:9000/v1/comment/bulk",
+			Method:  http.MethodPost,
+			Status:  http.StatusOK,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: fmt.Sprintf(`{"mode":"PARTIAL","operations":[%s,%s,%s]}`, create, invalid, missing),
+			Body:    fmt.Sprintf(`{"items":[{"status":201,"id":%d},{"status":400,"code":"VLD001","error":"comment: (description: cannot be blank.)."},{"status":404,"code":"GEN005","error":"resource not found"}]}`, nextID),
+		},
+		{
+			Name:    "v1 bulk comment without mode",
+			Route:   "http://localhost:9000/v1/comment/bulk",
+			Method:  http.MethodPost,
+			Status:  http.StatusBadRequest,
+			Headers: http.Header{"Authorization": {"Bearer " + token}},
+			Payload: fmt.Sprintf(`{"operations":[%s]}`, create),
+			Body:    `{"code":"VLD001","error":"mode: cannot be blank."}`,
 		},
 	}
 	for _, tc := range testCases {
@@ -5,8 +5,22 @@ import (
 	"go-boilerplate/common/response"
 	"go-boilerplate/repository"
 	"net/http"
+	"sync/atomic"
 )
 
+const shuttingDownStatus = "SHUTTING_DOWN"
+
+var shuttingDown = int32(0)
+
+// ShuttingDown flags the application as shutting down, making healthchecks fail so no new traffic is routed to it
+func ShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+func isShuttingDown() bool {
+	return atomic.LoadInt32(&shuttingDown) == 1
+}
+
 type simpleResponse struct {
 	Status string `json:"status"`
 }
@@ -14,7 +28,14 @@ type simpleResponse struct {
 // SimpleHandler handles simple healthcheck requests
 func SimpleHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	response.Write(w, simpleResponse{
+
+	if isShuttingDown() {
+		response.Write(w, r, simpleResponse{
+			Status: shuttingDownStatus,
+		}, http.StatusServiceUnavailable)
+		return
+	}
+	response.Write(w, r, simpleResponse{
 		Status: "OK",
 	}, http.StatusOK)
 }
@@ -23,10 +44,17 @@ func SimpleHandler(w http.ResponseWriter, r *http.Request) {
 func CompleteHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
+	if isShuttingDown() {
+		response.Write(w, r, simpleResponse{
+			Status: shuttingDownStatus,
+		}, http.StatusServiceUnavailable)
+		return
+	}
+
 	healthy := repository.Healthcheck()
 	if !healthy.Healthy() {
-		response.Write(w, healthy, http.StatusServiceUnavailable)
+		response.Write(w, r, healthy, http.StatusServiceUnavailable)
 		return
 	}
-	response.Write(w, healthy, http.StatusOK)
+	response.Write(w, r, healthy, http.StatusOK)
 }
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"go-boilerplate/common"
+	"go-boilerplate/common/metrics"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
+	gorillamux "github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+var (
+	// requestIDPattern of the request ids accepted from callers, others are replaced by a generated one
+	requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+	httpRequests = metrics.Factory().NewCounterVec(prometheus.CounterOpts{
+		Name: "http_server_requests_total",
+		Help: "Requests served by method, route template and status",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = metrics.Factory().NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_server_request_duration_seconds",
+		Help:    "Latency of the requests served by method, route template and status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// accessLog of a request, filled along the handlers chain and logged once the request is served
+type accessLog struct {
+	route     string
+	principal string
+	traceID   uint64
+	spanID    uint64
+}
+
+type accessLogContextKey struct{}
+
+func accessLogFrom(ctx context.Context) *accessLog {
+	entry, ok := ctx.Value(accessLogContextKey{}).(*accessLog)
+	if !ok {
+		return &accessLog{}
+	}
+	return entry
+}
+
+// statusRecorder keeps the status and the size of the response written
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestHandler accepts or generates the request id, returning it in the response,
+// and logs and measures each request once it's served, healthchecks and metrics aside
+func requestHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		entry := &accessLog{route: "unmatched"}
+		ctx := common.WithRequestID(r.Context(), requestID)
+		ctx = context.WithValue(ctx, accessLogContextKey{}, entry)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		start := time.Now()
+		defer func() {
+			if ignoredRequest(r) {
+				return
+			}
+			latency := time.Since(start)
+			status := strconv.Itoa(recorder.status)
+			httpRequests.WithLabelValues(r.Method, entry.route, status).Inc()
+			httpRequestDuration.WithLabelValues(r.Method, entry.route, status).Observe(latency.Seconds())
+
+			fields := common.LogFields(ctx)
+			fields["method"] = r.Method
+			fields["route"] = entry.route
+			fields["status"] = recorder.status
+			fields["bytes"] = recorder.bytes
+			fields["latency_ms"] = latency.Milliseconds()
+			if entry.principal != "" {
+				fields["principal"] = entry.principal
+			}
+			if entry.traceID != 0 {
+				fields["dd.trace_id"] = strconv.FormatUint(entry.traceID, 10)
+				fields["dd.span_id"] = strconv.FormatUint(entry.spanID, 10)
+			}
+			common.Logger.WithFields(fields).Infof("%s %s %d", r.Method, entry.route, recorder.status)
+		}()
+
+		h.ServeHTTP(recorder, r.WithContext(ctx))
+	})
+}
+
+// ignoredRequest by tracing, access logs and metrics, as they're done by probes and scrapers
+func ignoredRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/healthcheck") || r.URL.Path == "/metrics"
+}
+
+// routeHandler records the route template and the datadog span of the request matched by the router
+func routeHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := accessLogFrom(r.Context())
+		if route := gorillamux.CurrentRoute(r); route != nil {
+			entry.route, _ = route.GetPathTemplate()
+		}
+		if span, ok := tracer.SpanFromContext(r.Context()); ok {
+			entry.traceID = span.Context().TraceID()
+			entry.spanID = span.Context().SpanID()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// correlationHandler tags the sentry events of the request with its request id and datadog trace
+func correlationHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hub := sentry.GetHubFromContext(r.Context()); hub != nil {
+			for key, value := range common.LogFields(r.Context()) {
+				hub.Scope().SetTag(key, value.(string))
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/common/auth"
+	"go-boilerplate/common/response"
+	"go-boilerplate/domain/ratelimit"
+	ratelimitFacade "go-boilerplate/facade/ratelimit"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gorillamux "github.com/gorilla/mux"
+)
+
+var (
+	rateLimitEnabled       = common.Config.Get("rateLimitEnabled") == "true"
+	rateLimitProxyHops     = common.Config.GetInt("rateLimitTrustedProxyHops")
+	rateLimitPurgeInterval = time.Duration(common.Config.GetInt64("rateLimitPurgeIntervalSeconds")) * time.Second
+	defaultRateLimit       ratelimit.Limit
+	routeRateLimits        = map[string]ratelimit.Limit{}
+)
+
+// setupRateLimits parses the default limit and the per route ones (eg POST /v1/comment=60/1m), returning the longest period
+func setupRateLimits() (time.Duration, error) {
+	var err error
+	defaultRateLimit, err = ratelimit.ParseLimit(common.Config.Get("rateLimitDefault"))
+	if err != nil {
+		return 0, err
+	}
+	longest := defaultRateLimit.Period
+
+	for _, entry := range strings.Split(common.Config.Get("rateLimitRoutes"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok || len(strings.Fields(route)) != 2 {
+			return 0, fmt.Errorf("invalid route rate limit %s, expected METHOD route=limit", entry)
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return 0, err
+		}
+		routeRateLimits[strings.Join(strings.Fields(route), " ")] = limit
+		if limit.Period > longest {
+			longest = limit.Period
+		}
+	}
+
+	return longest, nil
+}
+
+// rateLimitHandler limits the requests of each client to a route following its limit, adding RateLimit headers to responses.
+// Requests are let through when the limit can't be checked, so the rate limit store isn't a single point of failure.
+// It runs before authentication, the given authenticate func identifies the clients whose token is valid
+func rateLimitHandler(h http.Handler, authenticate func(r *http.Request) (auth.Principal, bool)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rateLimitEnabled {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		scope := r.Method + " " + r.URL.Path
+		if route := gorillamux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				scope = r.Method + " " + template
+			}
+		}
+		limit, ok := routeRateLimits[scope]
+		if !ok {
+			limit = defaultRateLimit
+		}
+
+		result, err := ratelimitFacade.Get().Allow(r.Context(), scope+"|"+rateLimitClient(r, authenticate), limit)
+		if err != nil {
+			common.HandleErrorContext(r.Context(), "error checking rate limit", err)
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds())))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+			response.WriteTooManyRequestsError(w)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitClient identifies the client by its authenticated advertiser or account, or its ip otherwise.
+// Nothing the client sends unauthenticated, like an api key header, is used as it could change it on every request
+// to never be limited
+func rateLimitClient(r *http.Request, authenticate func(r *http.Request) (auth.Principal, bool)) string {
+	if principal, ok := authenticate(r); ok {
+		if principal.AdvertiserID != "" {
+			return "advertiser:" + principal.AdvertiserID
+		}
+		return "account:" + principal.AccountID
+	}
+
+	if ip := forwardedFor(r, rateLimitProxyHops); ip != "" {
+		return "ip:" + ip
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip:" + ip
+}
+
+// forwardedFor returns the client ip seen by the farthest of the given number of trusted proxies in front of the api.
+// Each proxy appends the address it was called from, so entries on the left of that one are set by the client itself
+func forwardedFor(r *http.Request, hops int) string {
+	if hops <= 0 {
+		return ""
+	}
+	var entries []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, entry := range strings.Split(header, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	if hops > len(entries) {
+		return entries[0]
+	}
+	return entries[len(entries)-hops]
+}
@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	"go-boilerplate/common/auth"
+	"go-boilerplate/test"
+	"net/http"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+)
+
+func TestRateLimit(t *testing.T) {
+	token, err := auth.GenerateToken(gofakeit.UUID(), gofakeit.UUID(), 1)
+	if err != nil {
+		t.Errorf("error generating token %s", err)
+		return
+	}
+	headers := http.Header{"Authorization": {"Bearer " + token}}
+
+	// attachments are limited to 30 requests per minute, rejected ones included
+	for i := 0; i < 30; i++ {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:9000/v1/comment/0/attachments", nil)
+		if err != nil {
+			t.Errorf("error creating request %s", err)
+			return
+		}
+		req.Header = headers.Clone()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("error calling api %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.Errorf("unexpected rate limited request %d", i)
+			return
+		}
+		if limit := resp.Header.Get("RateLimit-Limit"); limit != "30" {
+			t.Errorf("unexpected rate limit header %s", limit)
+		}
+	}
+
+	test.APITestCase{
+		Name:    "rate limited request",
+		Route:   "http://localhost:9000/v1/comment/0/attachments",
+		Method:  http.MethodPost,
+		Status:  http.StatusTooManyRequests,
+		Body:    `{"code":"GEN008","error":"Too Many Requests"}`,
+		Headers: headers.Clone(),
+	}.Run(t)
+
+	otherToken, err := auth.GenerateToken(gofakeit.UUID(), gofakeit.UUID(), 1)
+	if err != nil {
+		t.Errorf("error generating token %s", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:9000/v1/comment/0/attachments", nil)
+	if err != nil {
+		t.Errorf("error creating request %s", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+otherToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("error calling api %s", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.Errorf("unexpected rate limited request of another advertiser")
+	}
+}
+
+func TestRateLimitUnauthenticated(t *testing.T) {
+	// unauthenticated requests are limited by their ip, whatever api key they send
+	for i := 0; i < 30; i++ {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:9000/v1/comment/0/attachments", nil)
+		if err != nil {
+			t.Errorf("error creating request %s", err)
+			return
+		}
+		req.Header.Set("X-API-Key", gofakeit.UUID())
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("error calling api %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected status of unauthenticated request %d %d", i, resp.StatusCode)
+			return
+		}
+	}
+
+	test.APITestCase{
+		Name:    "rate limited unauthenticated request",
+		Route:   "http://localhost:9000/v1/comment/0/attachments",
+		Method:  http.MethodPost,
+		Status:  http.StatusTooManyRequests,
+		Body:    `{"code":"GEN008","error":"Too Many Requests"}`,
+		Headers: http.Header{"X-API-Key": {gofakeit.UUID()}},
+	}.Run(t)
+}
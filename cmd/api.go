@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"go-boilerplate/api"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,8 @@ func init() {
 }
 
 func apiExecute(cmd *cobra.Command, args []string) error {
-	api.Setup()
-	return nil
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return api.Setup(ctx)
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"go-boilerplate/common"
+	commentFacade "go-boilerplate/facade/comment"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	commentPurgeCommand = &cobra.Command{
+		Use:   "comment-purge",
+		Short: "Purges soft deleted comments",
+		Long:  "Permanently deletes comments, and their revisions, soft deleted before the retention window.",
+		RunE:  commentPurgeExecute,
+	}
+
+	commentPurgeRetentionDays int
+)
+
+func init() {
+	commentPurgeCommand.Flags().IntVar(&commentPurgeRetentionDays, "retention-days", common.Config.GetInt("commentDeletedRetentionDays"), "days soft deleted comments are kept before being purged")
+	RootCmd.AddCommand(commentPurgeCommand)
+}
+
+func commentPurgeExecute(cmd *cobra.Command, args []string) error {
+	before := time.Now().Add(-time.Duration(commentPurgeRetentionDays) * common.ADay)
+
+	count, err := commentFacade.Get().Purge(cmd.Context(), before)
+	if err != nil {
+		return err
+	}
+	common.Logger.Infof("purged %d comments soft deleted before %s", count, before.Format(time.RFC3339))
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"go-boilerplate/common"
+	idempotencyFacade "go-boilerplate/facade/idempotency"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	idempotencyKeyPurgeCommand = &cobra.Command{
+		Use:   "idempotency-key-purge",
+		Short: "Purges expired idempotency keys",
+		Long:  "Deletes idempotency keys created before their ttl, requests sent along them are no longer replayed.",
+		RunE:  idempotencyKeyPurgeExecute,
+	}
+
+	idempotencyKeyTTLHours int
+)
+
+func init() {
+	idempotencyKeyPurgeCommand.Flags().IntVar(&idempotencyKeyTTLHours, "ttl-hours", common.Config.GetInt("idempotencyKeyTtlHours"), "hours idempotency keys are kept before being purged")
+	RootCmd.AddCommand(idempotencyKeyPurgeCommand)
+}
+
+func idempotencyKeyPurgeExecute(cmd *cobra.Command, args []string) error {
+	before := time.Now().Add(-time.Duration(idempotencyKeyTTLHours) * time.Hour)
+
+	count, err := idempotencyFacade.Get().Purge(cmd.Context(), before)
+	if err != nil {
+		return err
+	}
+	common.Logger.Infof("purged %d idempotency keys created before %s", count, before.Format(time.RFC3339))
+
+	return nil
+}
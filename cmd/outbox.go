@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"go-boilerplate/common"
+	"go-boilerplate/facade"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	outboxRelayCommand = &cobra.Command{
+		Use:   "outbox-relay",
+		Short: "Relays outbox events to the queue",
+		Long:  "Periodically publishes pending outbox events to the queue, retrying the ones which failed to be published after commit, until it's interrupted.",
+		RunE:  outboxRelayExecute,
+	}
+
+	outboxPurgeCommand = &cobra.Command{
+		Use:   "outbox-purge",
+		Short: "Purges published outbox events",
+		Long:  "Deletes outbox events published before their retention, pending and dead events are kept.",
+		RunE:  outboxPurgeExecute,
+	}
+
+	outboxRelayInterval           time.Duration
+	outboxPublishedRetentionHours int
+)
+
+func init() {
+	outboxRelayCommand.Flags().DurationVar(&outboxRelayInterval, "interval", time.Duration(common.Config.GetInt64("outboxRelayIntervalSeconds"))*time.Second, "interval between outbox relay runs")
+	RootCmd.AddCommand(outboxRelayCommand)
+
+	outboxPurgeCommand.Flags().IntVar(&outboxPublishedRetentionHours, "retention-hours", common.Config.GetInt("outboxPublishedRetentionHours"), "hours published outbox events are kept before being purged")
+	RootCmd.AddCommand(outboxPurgeCommand)
+}
+
+func outboxRelayExecute(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	relay := facade.GetOutboxRelay()
+	ticker := time.NewTicker(outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// keeps relaying while there are full batches of pending events
+		for ctx.Err() == nil {
+			published, err := relay.Relay(ctx, nil)
+			if err != nil {
+				common.HandleError("error relaying outbox events", err)
+				break
+			}
+			if published > 0 {
+				common.Logger.Infof("relayed %d outbox events", published)
+			}
+			if published < relay.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+func outboxPurgeExecute(cmd *cobra.Command, args []string) error {
+	before := time.Now().Add(-time.Duration(outboxPublishedRetentionHours) * time.Hour)
+
+	count, err := facade.GetOutboxRelay().Purge(cmd.Context(), before)
+	if err != nil {
+		return err
+	}
+	common.Logger.Infof("purged %d outbox events published before %s", count, before.Format(time.RFC3339))
+
+	return nil
+}
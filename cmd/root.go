@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"time"
 
 	"go-boilerplate/common"
+	"go-boilerplate/facade"
+	"go-boilerplate/repository"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/spf13/cobra"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
 // RootCmd holds reference to root cmd to be used by children commands
@@ -17,22 +22,47 @@ var RootCmd = &cobra.Command{
 	Long:  "go Boilerplate API",
 }
 
-// Execute executes root cmd
+// Execute executes root cmd and exits with its status code
 func Execute() {
 	defer func() {
 		err := recover()
 		if err != nil {
 			casted, ok := err.(error)
-			if ok {
-				common.HandleError("unexpected error while executing command", casted)
+			if !ok {
+				casted = fmt.Errorf("%v", err)
 			}
+			common.HandleError("unexpected error while executing command", casted)
+			Exit(-1)
 		}
 	}()
 
 	err := RootCmd.Execute()
 	if err != nil {
 		common.HandleError("error while executing command", err)
-		sentry.Flush(time.Second * 2)
-		os.Exit(-1)
+		Exit(-1)
 	}
+	Exit(0)
+}
+
+// Exit releases app resources in order, waiting for outbox relays first, then database and http client, then tracer and sentry,
+// and exits with the given code
+func Exit(code int) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(common.Config.GetInt64("outboxRelayTimeoutSeconds"))*time.Second)
+	err := facade.DrainRelays(ctx)
+	cancel()
+	if err != nil {
+		common.HandleError("error waiting for outbox relays", err)
+	}
+
+	err = repository.Close()
+	if err != nil {
+		common.HandleError("error closing repository", err)
+	}
+
+	if common.Config.Get("datadogEnabled") == "true" {
+		tracer.Stop()
+	}
+	sentry.Flush(time.Second * 5)
+
+	os.Exit(code)
 }
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"go-boilerplate/worker"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	workerCommand = &cobra.Command{
+		Use:   "worker",
+		Short: "Initializes the queue worker",
+		Long:  "Initializes the worker consuming the given queues, all registered ones by default, until it's interrupted.",
+		RunE:  workerExecute,
+	}
+
+	workerQueues []string
+)
+
+func init() {
+	workerCommand.Flags().StringSliceVar(&workerQueues, "queues", worker.Queues(), "queues to be consumed")
+	RootCmd.AddCommand(workerCommand)
+}
+
+func workerExecute(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return worker.Setup(ctx, workerQueues)
+}
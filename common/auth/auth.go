@@ -0,0 +1,154 @@
+// Package auth holds request authentication and the authenticated principal
+package auth
+
+import (
+	"context"
+	"errors"
+	"go-boilerplate/common"
+	"go-boilerplate/domain"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	bearerPrefix = "Bearer "
+
+	// audiences of the tokens, so a token of one kind isn't accepted as another even if their secrets leak or match.
+	// Api tokens are generated by domain.GenerateAccessToken without audience, so they are accepted with or without it.
+	// Media tokens keep that format too, they are told apart by their secret only
+	apiAudience   = "api"
+	adminAudience = "admin"
+)
+
+type principalContextKey struct{}
+
+var (
+	// ErrMissingToken is when the request has no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is when a token isn't of the expected audience or lacks the principal claims
+	ErrInvalidToken = errors.New("invalid token")
+
+	jwtSecret      = common.Config.Get("apiJwtSecret")
+	adminJwtSecret = common.Config.Get("adminJwtSecret")
+
+	mediaJwtSecret   = common.Config.Get("mediaUploadJwtSecret")
+	mediaJwtExpHours = common.Config.GetInt("mediaUploadJwtExpHours")
+)
+
+// Setup checks the token secrets, each kind of token must have its own secret
+func Setup() error {
+	if jwtSecret == mediaJwtSecret || jwtSecret == adminJwtSecret || adminJwtSecret == mediaJwtSecret {
+		return errors.New("api, admin and media upload jwt secrets must be different")
+	}
+	return nil
+}
+
+// Principal is who is performing a request
+type Principal struct {
+	AccountID    string
+	AdvertiserID string
+}
+
+// CanAccess tells if the principal can act on resources of the given advertiser and account
+func (p Principal) CanAccess(advertiserID, accountID string) bool {
+	return p.AdvertiserID == advertiserID && p.AccountID == accountID
+}
+
+// FromRequest parses the principal from the request bearer token
+func FromRequest(r *http.Request) (Principal, error) {
+	return fromBearer(r, jwtSecret, apiAudience, false)
+}
+
+// FromAdminRequest parses the principal from the request bearer token, only admin tokens are accepted
+func FromAdminRequest(r *http.Request) (Principal, error) {
+	return fromBearer(r, adminJwtSecret, adminAudience, true)
+}
+
+func fromBearer(r *http.Request, secret, audience string, requireAudience bool) (Principal, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return Principal{}, ErrMissingToken
+	}
+
+	return parseToken(strings.TrimPrefix(header, bearerPrefix), secret, audience, requireAudience)
+}
+
+// WithPrincipal returns a copy of the given context holding the principal
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// FromContext returns the principal held by the given context
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
+
+// GenerateToken generates a bearer token for the given account and advertiser
+func GenerateToken(accountID, advertiserID string, expHours int) (string, error) {
+	return domain.GenerateAccessToken(accountID, advertiserID, jwtSecret, expHours)
+}
+
+// GenerateAdminToken generates a bearer token for the given admin account, it isn't bound to any advertiser
+func GenerateAdminToken(accountID string, expHours int) (string, error) {
+	return generateToken(accountID, "", adminJwtSecret, adminAudience, expHours)
+}
+
+// GenerateMediaToken generates a media upload token granting unauthenticated access to the documents of the given
+// account and advertiser, it's the domain.GenerateAccessToken format so existing media consumers can verify it
+func GenerateMediaToken(accountID, advertiserID string) (string, error) {
+	return domain.GenerateAccessToken(accountID, advertiserID, mediaJwtSecret, mediaJwtExpHours)
+}
+
+// ParseMediaToken parses the principal from a media upload token, access to a document must still be checked against it
+func ParseMediaToken(token string) (Principal, error) {
+	accountID, advertiserID, err := domain.ParseAccessToken(token, mediaJwtSecret)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return Principal{
+		AccountID:    accountID,
+		AdvertiserID: advertiserID,
+	}, nil
+}
+
+// generateToken of the given audience for the given account and advertiser
+func generateToken(accountID, advertiserID, secret, audience string, expHours int) (string, error) {
+	return common.CreateJWTToken(jwt.MapClaims{
+		"accountId":    accountID,
+		"advertiserId": advertiserID,
+		"aud":          audience,
+		"exp":          time.Now().Add(time.Duration(expHours) * time.Hour).Unix(),
+	}, secret)
+}
+
+// parseToken parses the principal from a token of the given audience, a token without audience is only accepted when it
+// isn't required
+func parseToken(tokenStr, secret, audience string, requireAudience bool) (Principal, error) {
+	token, err := common.ParseJWTToken(tokenStr, secret)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || !claims.VerifyAudience(audience, requireAudience) {
+		return Principal{}, ErrInvalidToken
+	}
+	accountID, ok := claims["accountId"].(string)
+	if !ok {
+		return Principal{}, ErrInvalidToken
+	}
+	advertiserID, ok := claims["advertiserId"].(string)
+	if !ok {
+		return Principal{}, ErrInvalidToken
+	}
+
+	return Principal{
+		AccountID:    accountID,
+		AdvertiserID: advertiserID,
+	}, nil
+}
@@ -0,0 +1,240 @@
+package auth_test
+
+import (
+	"context"
+	"go-boilerplate/common"
+	"go-boilerplate/common/auth"
+	"go-boilerplate/domain"
+	"go-boilerplate/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetup(t *testing.T) {
+	err := auth.Setup()
+	test.AssertError(t, err, "")
+}
+
+func TestFromRequest(t *testing.T) {
+	accountID := gofakeit.UUID()
+	advertiserID := gofakeit.UUID()
+	token, _ := auth.GenerateToken(accountID, advertiserID, 1)
+	expired, _ := auth.GenerateToken(accountID, advertiserID, -1)
+	mediaToken, _ := auth.GenerateMediaToken(accountID, advertiserID)
+	otherAudience, _ := common.CreateJWTToken(jwt.MapClaims{
+		"accountId":    accountID,
+		"advertiserId": advertiserID,
+		"aud":          "media",
+	}, common.Config.Get("apiJwtSecret"))
+	withAudience, _ := common.CreateJWTToken(jwt.MapClaims{
+		"accountId":    accountID,
+		"advertiserId": advertiserID,
+		"aud":          "api",
+	}, common.Config.Get("apiJwtSecret"))
+	withoutAudience, _ := domain.GenerateAccessToken(accountID, advertiserID, common.Config.Get("apiJwtSecret"), 1)
+
+	testCases := []struct {
+		name          string
+		header        string
+		expected      auth.Principal
+		expectedError string
+	}{
+		{
+			name:   "valid token",
+			header: "Bearer " + token,
+			expected: auth.Principal{
+				AccountID:    accountID,
+				AdvertiserID: advertiserID,
+			},
+		},
+		{
+			name:   "token with api audience",
+			header: "Bearer " + withAudience,
+			expected: auth.Principal{
+				AccountID:    accountID,
+				AdvertiserID: advertiserID,
+			},
+		},
+		{
+			name:          "missing token",
+			expectedError: "missing bearer token",
+		},
+		{
+			name:          "not a bearer token",
+			header:        "Basic " + token,
+			expectedError: "missing bearer token",
+		},
+		{
+			name:          "expired token",
+			header:        "Bearer " + expired,
+			expectedError: "Token is expired",
+		},
+		{
+			name:          "media token",
+			header:        "Bearer " + mediaToken,
+			expectedError: "signature is invalid",
+		},
+		{
+			name:          "token of another audience",
+			header:        "Bearer " + otherAudience,
+			expectedError: "invalid token",
+		},
+		{
+			name:   "token without audience",
+			header: "Bearer " + withoutAudience,
+			expected: auth.Principal{
+				AccountID:    accountID,
+				AdvertiserID: advertiserID,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/comment", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+
+			p, err := auth.FromRequest(r)
+			test.AssertError(t, err, tc.expectedError)
+			if diff := cmp.Diff(p, tc.expected); diff != "" {
+				t.Errorf("unexpected principal %s", diff)
+			}
+		})
+	}
+}
+
+func TestFromAdminRequest(t *testing.T) {
+	accountID := gofakeit.UUID()
+	adminToken, _ := auth.GenerateAdminToken(accountID, 1)
+	token, _ := auth.GenerateToken(accountID, gofakeit.UUID(), 1)
+	withoutAudience, _ := domain.GenerateAccessToken(accountID, "", common.Config.Get("adminJwtSecret"), 1)
+
+	testCases := []struct {
+		name          string
+		header        string
+		expected      auth.Principal
+		expectedError string
+	}{
+		{
+			name:   "valid admin token",
+			header: "Bearer " + adminToken,
+			expected: auth.Principal{
+				AccountID: accountID,
+			},
+		},
+		{
+			name:          "advertiser token",
+			header:        "Bearer " + token,
+			expectedError: "signature is invalid",
+		},
+		{
+			name:          "admin token without audience",
+			header:        "Bearer " + withoutAudience,
+			expectedError: "invalid token",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/v1/admin/advertiser", nil)
+			r.Header.Set("Authorization", tc.header)
+
+			p, err := auth.FromAdminRequest(r)
+			test.AssertError(t, err, tc.expectedError)
+			if diff := cmp.Diff(p, tc.expected); diff != "" {
+				t.Errorf("unexpected principal %s", diff)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	p := auth.Principal{
+		AccountID:    gofakeit.UUID(),
+		AdvertiserID: gofakeit.UUID(),
+	}
+
+	_, ok := auth.FromContext(context.Background())
+	if ok {
+		t.Errorf("unexpected principal in empty context")
+		return
+	}
+
+	result, ok := auth.FromContext(auth.WithPrincipal(context.Background(), p))
+	if !ok || result != p {
+		t.Errorf("unexpected principal from context %+v", result)
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	p := auth.Principal{
+		AccountID:    gofakeit.UUID(),
+		AdvertiserID: gofakeit.UUID(),
+	}
+
+	if !p.CanAccess(p.AdvertiserID, p.AccountID) {
+		t.Errorf("principal should access its own resources")
+	}
+	if p.CanAccess(gofakeit.UUID(), p.AccountID) {
+		t.Errorf("principal should not access other advertiser resources")
+	}
+	if p.CanAccess(p.AdvertiserID, gofakeit.UUID()) {
+		t.Errorf("principal should not access other account resources")
+	}
+}
+
+func TestParseMediaToken(t *testing.T) {
+	accountID := gofakeit.UUID()
+	advertiserID := gofakeit.UUID()
+	token, _ := auth.GenerateMediaToken(accountID, advertiserID)
+	accessToken, _ := domain.GenerateAccessToken(accountID, advertiserID, common.Config.Get("mediaUploadJwtSecret"), 1)
+	apiToken, _ := auth.GenerateToken(accountID, advertiserID, 1)
+
+	testCases := []struct {
+		name          string
+		token         string
+		expected      auth.Principal
+		expectedError string
+	}{
+		{
+			name:  "valid token",
+			token: token,
+			expected: auth.Principal{
+				AccountID:    accountID,
+				AdvertiserID: advertiserID,
+			},
+		},
+		{
+			name:  "access token of the media upload secret",
+			token: accessToken,
+			expected: auth.Principal{
+				AccountID:    accountID,
+				AdvertiserID: advertiserID,
+			},
+		},
+		{
+			name:          "malformed token",
+			token:         gofakeit.Word(),
+			expectedError: "token contains an invalid number of segments",
+		},
+		{
+			name:          "api token",
+			token:         apiToken,
+			expectedError: "signature is invalid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := auth.ParseMediaToken(tc.token)
+			test.AssertError(t, err, tc.expectedError)
+			if diff := cmp.Diff(p, tc.expected); diff != "" {
+				t.Errorf("unexpected principal %s", diff)
+			}
+		})
+	}
+}
@@ -2,12 +2,11 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
-	"runtime/debug"
 	"time"
 
-	"github.com/getsentry/sentry-go"
 	"github.com/golang-jwt/jwt"
 	"github.com/olxbr/ligeiro/envcfg"
 	"github.com/olxbr/ligeiro/logger"
@@ -31,10 +30,46 @@ var Config = envcfg.Load(envcfg.Map{
 	"ZAP_PORTAL_HOST":               "www.zapimoveis.com.br",
 	"MY_ACCOUNT_API_VIVA_REAL_HOST": "my-account-api.vivareal.com.br",
 	"MY_ACCOUNT_API_ZAP_HOST":       "my-account-api.zapimoveis.com.br",
+	"API_JWT_SECRET":                "local-api-secret",
+	"ADMIN_JWT_SECRET":              "local-admin-secret",
 	"MEDIA_UPLOAD_JWT_SECRET":       "local-secret",
 	"MEDIA_UPLOAD_JWT_EXP_HOURS":    "1",
 	"WORKDAY_START_HOUR":            "8",
 	"WORKDAY_PERIOD_IN_HOURS":       "10",
+	"LOCK_NAMESPACE":                "go-boilerplate",
+
+	// Comment Config
+	"COMMENT_DELETED_RETENTION_DAYS":            "30",
+	"COMMENT_ATTACHMENTS_BUCKET":                "email-attachments-bucket",
+	"COMMENT_ATTACHMENT_URL_EXPIRATION_MINUTES": "15",
+	"COMMENT_EVENTS_QUEUE":                      "comment-events",
+	"COMMENT_IMPORT_QUEUE":                      "comment-import",
+	"COMMENT_IMPORT_DEAD_LETTER_QUEUE":          "comment-import-dlq",
+	"COMMENT_BULK_MAX_OPERATIONS":               "500",
+
+	// Advertiser Config
+	"ADVERTISER_CONFIG_BUCKET":                   "advertiser-config",
+	"ADVERTISER_CONFIG_KEY":                      "dev-configs.json",
+	"ADVERTISER_CONFIG_REFRESH_INTERVAL_SECONDS": "60",
+
+	// Worker Config
+	"WORKER_CONCURRENCY":                "10",
+	"WORKER_MAX_RECEIVES":               "5",
+	"WORKER_WAIT_SECONDS":               "20",
+	"WORKER_VISIBILITY_TIMEOUT_SECONDS": "30",
+	"WORKER_SHUTDOWN_TIMEOUT_SECONDS":   "30",
+
+	// Outbox Config
+	"OUTBOX_BATCH_SIZE":                "100",
+	"OUTBOX_MAX_ATTEMPTS":              "10",
+	"OUTBOX_BACKOFF_SECONDS":           "1",
+	"OUTBOX_MAX_BACKOFF_SECONDS":       "300",
+	"OUTBOX_RELAY_INTERVAL_SECONDS":    "5",
+	"OUTBOX_RELAY_TIMEOUT_SECONDS":     "30",
+	"OUTBOX_PUBLISHED_RETENTION_HOURS": "168",
+
+	// Idempotency Config
+	"IDEMPOTENCY_KEY_TTL_HOURS": "24",
 
 	// DB Config
 	"DB_HOST":            "localhost",
@@ -47,19 +82,40 @@ var Config = envcfg.Load(envcfg.Map{
 	"DB_TIMEOUT_SECONDS": "2",
 
 	// Http Server Config
-	"HTTP_SERVER_READ_TIMEOUT_SECONDS":  "600",
-	"HTTP_SERVER_WRITE_TIMEOUT_SECONDS": "600",
-	"HTTP_HEALTHCHECK_ENDPOINT":         "",
+	"HTTP_SERVER_READ_TIMEOUT_SECONDS":     "600",
+	"HTTP_SERVER_WRITE_TIMEOUT_SECONDS":    "600",
+	"HTTP_SERVER_SHUTDOWN_DELAY_SECONDS":   "5",
+	"HTTP_SERVER_SHUTDOWN_TIMEOUT_SECONDS": "30",
+	"HTTP_HEALTHCHECK_ENDPOINT":            "",
+
+	// Rate limits as requests/period, per route overrides as comma separated METHOD route=limit.
+	// X-Forwarded-For is only used to identify clients behind the given number of trusted proxies
+	"RATE_LIMIT_ENABLED":                    "true",
+	"RATE_LIMIT_STORE":                      "memory",
+	"RATE_LIMIT_DEFAULT":                    "300/1m",
+	"RATE_LIMIT_ROUTES":                     "POST /v1/comment/{id:[0-9]+}/attachments=30/1m,POST /v1/comment/bulk=10/1m",
+	"RATE_LIMIT_TRUSTED_PROXY_HOPS":         "0",
+	"RATE_LIMIT_PURGE_INTERVAL_SECONDS":     "300",
+	"RATE_LIMIT_STORE_TIMEOUT_MILLISECONDS": "200",
 
 	// AWS Config
-	"AWS_REGION": "us-east-1",
+	"AWS_REGION":   "us-east-1",
+	"AWS_ENDPOINT": "",
 
 	// HTTP Default Configurations
-	"HTTP_TIMEOUT_SECONDS": "600",
-	"HTTP_MIN_CONNECTIONS": "10",
-	"HTTP_MAX_CONNECTIONS": "10",
-	"HTTP_RESPONSE_DEBUG":  "false",
-	"HTTP_MAX_RETRIES":     "1",
+	"HTTP_TIMEOUT_SECONDS":           "600",
+	"HTTP_MIN_CONNECTIONS":           "10",
+	"HTTP_MAX_CONNECTIONS":           "10",
+	"HTTP_RESPONSE_DEBUG":            "false",
+	"HTTP_MAX_RETRIES":               "1",
+	"HTTP_BACKOFF_MILLISECONDS":      "200",
+	"HTTP_MAX_BACKOFF_SECONDS":       "10",
+	"HTTP_BREAKER_FAILURE_THRESHOLD": "5",
+	"HTTP_BREAKER_OPEN_SECONDS":      "30",
+	"HTTP_MAX_RESPONSE_BYTES":        "10485760",
+	"HTTP_TLS_CA_FILE":               "",
+	"HTTP_TLS_CLIENT_CERTS":          "",
+	"HTTP_TLS_INSECURE_HOSTS":        "",
 })
 
 // Logger is the default app logger
@@ -73,13 +129,9 @@ func ToTime(v string) (time.Time, error) {
 	return time.Parse(time.RFC3339Nano, v)
 }
 
-// HandleError handles errors sending them to sentry and logging
+// HandleError handles errors sending them to sentry and logging, see HandleErrorContext when there's a context
 func HandleError(message string, err error) {
-	Logger.WithFields(logger.Fields{
-		"error": err,
-		"stack": string(debug.Stack()),
-	}).Error(message)
-	sentry.CaptureException(err)
+	HandleErrorContext(context.Background(), message, err)
 }
 
 // CreateJWTToken given a set o claims and a secret create a jwt token
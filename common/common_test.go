@@ -1,6 +1,7 @@
 package common_test
 
 import (
+	"context"
 	"go-boilerplate/common"
 	"testing"
 	"time"
@@ -27,3 +28,30 @@ func TestQuotedStringBytes(t *testing.T) {
 		t.Errorf("unexpected quoted string value %s", result)
 	}
 }
+
+func TestLogFields(t *testing.T) {
+	if fields := common.LogFields(context.Background()); len(fields) != 0 {
+		t.Errorf("unexpected log fields without request %v", fields)
+	}
+
+	ctx := common.WithRequestID(context.Background(), "a1b2c3")
+	if requestID := common.RequestID(ctx); requestID != "a1b2c3" {
+		t.Errorf("unexpected request id %s", requestID)
+	}
+	if fields := common.LogFields(ctx); fields["request_id"] != "a1b2c3" {
+		t.Errorf("unexpected log fields %v", fields)
+	}
+}
+
+func TestDetachedContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(common.WithRequestID(context.Background(), "a1b2c3"))
+	cancel()
+
+	detached := common.DetachedContext(ctx)
+	if detached.Err() != nil {
+		t.Errorf("unexpected detached context error %s", detached.Err())
+	}
+	if requestID := common.RequestID(detached); requestID != "a1b2c3" {
+		t.Errorf("unexpected detached request id %s", requestID)
+	}
+}
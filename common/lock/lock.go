@@ -1,32 +1,184 @@
-// Package lock holds distributed lock utility
+// Package lock holds distributed lock utility backed by postgres advisory locks.
+// Keys are namespaced by service, so services sharing a database don't collide
 package lock
 
-import "go-boilerplate/repository"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/repository"
+	"hash/crc32"
+	"time"
+)
 
-// Key to lock
-type Key int
+// Key to lock, unique within the service namespace
+type Key int32
 
 const (
 	// AdvertiserPortalsConfigUpdate key to lock when advertisers config file is updated in S3
 	AdvertiserPortalsConfigUpdate Key = iota
 )
 
-// Acquire lock of given key
-func Acquire(key Key) error {
-	_, err := repository.DB.Exec(`SELECT pg_advisory_lock($1)`, key)
+const (
+	// pollInterval between attempts while waiting for a lock
+	pollInterval = 100 * time.Millisecond
+	// releaseTimeout of the unlock, the connection is discarded when it's over
+	releaseTimeout = 5 * time.Second
+)
+
+var (
+	// ErrNotHeld is when a lock is released but it wasn't held by its connection
+	ErrNotHeld = errors.New("lock not held")
+
+	// namespace is the first int of the two int advisory lock keys, the second one is the lock key
+	namespace = int32(crc32.ChecksumIEEE([]byte(common.Config.Get("lockNamespace"))))
+
+	instance = &lockerImpl{}
+)
+
+// Locker to enable locks to be mocked
+type Locker interface {
+	// WithLock executes the given func holding the lock of the given key, waiting for it until the context is done
+	WithLock(ctx context.Context, key Key, fn func() error) error
+}
+
+type lockerImpl struct{}
+
+// Get this locker instance
+func Get() Locker {
+	return instance
+}
+
+func (l *lockerImpl) WithLock(ctx context.Context, key Key, fn func() error) error {
+	return WithLock(ctx, key, fn)
+}
+
+// Lock held in a dedicated connection, advisory locks are bound to the session which acquired them
+type Lock struct {
+	key  Key
+	conn *sql.Conn
+}
+
+// TryAcquire the lock of the given key without waiting, it's not acquired when another session holds it
+func TryAcquire(ctx context.Context, key Key) (*Lock, bool, error) {
+	conn, err := repository.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	acquired, err := tryAcquire(ctx, conn, key)
 	if err != nil {
+		discard(conn)
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return &Lock{key: key, conn: conn}, true, nil
+}
+
+// Acquire the lock of the given key waiting for it until the context is done.
+// It polls the lock instead of blocking on it so the wait isn't bounded by the statement timeout
+func Acquire(ctx context.Context, key Key) (*Lock, error) {
+	conn, err := repository.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		acquired, err := tryAcquire(ctx, conn, key)
+		if err != nil {
+			discard(conn)
+			return nil, err
+		}
+		if acquired {
+			return &Lock{key: key, conn: conn}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, fmt.Errorf("error acquiring lock %d: %w", key, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// AcquireTimeout acquires the lock of the given key waiting for it until the timeout is over
+func AcquireTimeout(ctx context.Context, key Key, timeout time.Duration) (*Lock, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return Acquire(ctx, key)
+}
+
+// Release the lock and its connection, the lock can't be used afterwards.
+// When the unlock fails the connection is discarded instead of returned to the pool, ending the session and its locks
+func (l *Lock) Release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	released := false
+	err := l.conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, namespace, l.key).Scan(&released)
+	if err != nil {
+		discard(l.conn)
 		return err
 	}
+	l.conn.Close()
+	if !released {
+		return fmt.Errorf("error releasing lock %d: %w", l.key, ErrNotHeld)
+	}
 
 	return nil
 }
 
-// Release lock of given key
-func Release(key Key) error {
-	_, err := repository.DB.Exec(`SELECT pg_advisory_unlock($1)`, key)
+// WithLock executes the given func holding the lock of the given key, waiting for it until the context is done.
+// The lock is released when the func returns, even if it panics
+func WithLock(ctx context.Context, key Key, fn func() error) (err error) {
+	l, err := Acquire(ctx, key)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		releaseErr := l.Release()
+		if err == nil {
+			err = releaseErr
+		}
+	}()
 
-	return nil
+	return fn()
+}
+
+// AcquireTx acquires the lock of the given key until the given transaction ends, waiting for it up to the statement timeout.
+// It's meant to be used inside WithTxManager, the lock is released by the commit or rollback
+func AcquireTx(ctx context.Context, tx *sql.Tx, key Key) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, namespace, key)
+	return err
+}
+
+// TryAcquireTx acquires the lock of the given key until the given transaction ends without waiting,
+// it's not acquired when another session holds it
+func TryAcquireTx(ctx context.Context, tx *sql.Tx, key Key) (bool, error) {
+	acquired := false
+	err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1, $2)`, namespace, key).Scan(&acquired)
+	return acquired, err
+}
+
+func tryAcquire(ctx context.Context, conn *sql.Conn, key Key) (bool, error) {
+	acquired := false
+	err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, namespace, key).Scan(&acquired)
+	return acquired, err
+}
+
+// discard the connection instead of returning it to the pool, a session which may still hold a lock must not be reused
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
 }
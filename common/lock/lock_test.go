@@ -1,11 +1,14 @@
 package lock_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go-boilerplate/common/lock"
 	"go-boilerplate/repository"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestMain(m *testing.M) {
@@ -19,14 +22,107 @@ func TestMain(m *testing.M) {
 }
 
 func TestAcquireRelease(t *testing.T) {
-	err := lock.Acquire(34)
+	l, err := lock.Acquire(context.Background(), 34)
 	if err != nil {
 		t.Errorf("error acquiring lock %s", err)
 		return
 	}
 
-	err = lock.Release(34)
+	err = l.Release()
 	if err != nil {
 		t.Errorf("error releasing lock %s", err)
 	}
+
+	err = l.Release()
+	if err == nil {
+		t.Errorf("expected error releasing lock twice")
+	}
+}
+
+func TestTryAcquire(t *testing.T) {
+	l, acquired, err := lock.TryAcquire(context.Background(), 35)
+	if err != nil || !acquired {
+		t.Errorf("error acquiring lock %t %s", acquired, err)
+		return
+	}
+	defer l.Release()
+
+	_, acquired, err = lock.TryAcquire(context.Background(), 35)
+	if err != nil {
+		t.Errorf("error trying to acquire lock %s", err)
+	}
+	if acquired {
+		t.Errorf("unexpected lock acquired while held by another connection")
+	}
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	l, err := lock.Acquire(context.Background(), 36)
+	if err != nil {
+		t.Errorf("error acquiring lock %s", err)
+		return
+	}
+
+	_, err = lock.AcquireTimeout(context.Background(), 36, 300*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error acquiring held lock %v", err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l.Release()
+	}()
+	waiting, err := lock.AcquireTimeout(context.Background(), 36, 5*time.Second)
+	if err != nil {
+		t.Errorf("error acquiring released lock %s", err)
+		return
+	}
+	waiting.Release()
+}
+
+func TestWithLock(t *testing.T) {
+	fnErr := errors.New("fn failed")
+	err := lock.WithLock(context.Background(), 37, func() error {
+		_, acquired, err := lock.TryAcquire(context.Background(), 37)
+		if err != nil || acquired {
+			t.Errorf("unexpected lock acquired while held %t %v", acquired, err)
+		}
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	l, acquired, err := lock.TryAcquire(context.Background(), 37)
+	if err != nil || !acquired {
+		t.Errorf("lock not released after fn %t %v", acquired, err)
+		return
+	}
+	l.Release()
+}
+
+func TestAcquireTx(t *testing.T) {
+	tx, err := repository.DB.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Errorf("error starting transaction %s", err)
+		return
+	}
+
+	err = lock.AcquireTx(context.Background(), tx, 38)
+	if err != nil {
+		t.Errorf("error acquiring transaction lock %s", err)
+	}
+	_, acquired, err := lock.TryAcquire(context.Background(), 38)
+	if err != nil || acquired {
+		t.Errorf("unexpected lock acquired while held by transaction %t %v", acquired, err)
+	}
+
+	tx.Rollback()
+
+	l, acquired, err := lock.TryAcquire(context.Background(), 38)
+	if err != nil || !acquired {
+		t.Errorf("lock not released by rollback %t %v", acquired, err)
+		return
+	}
+	l.Release()
 }
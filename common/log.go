@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/olxbr/ligeiro/logger"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of the given context holding the request id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestID held by the given context, empty when it isn't bound to a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// DetachedContext returns a context outliving the given one, for work which must go on after it's done or canceled,
+// still correlated with its request and datadog trace
+func DetachedContext(ctx context.Context) context.Context {
+	detached := WithRequestID(context.Background(), RequestID(ctx))
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		detached = tracer.ContextWithSpan(detached, span)
+	}
+	return detached
+}
+
+// LogFields correlating logs with the request and the datadog trace of the given context
+func LogFields(ctx context.Context) logger.Fields {
+	fields := logger.Fields{}
+	if requestID := RequestID(ctx); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		fields["dd.trace_id"] = strconv.FormatUint(span.Context().TraceID(), 10)
+		fields["dd.span_id"] = strconv.FormatUint(span.Context().SpanID(), 10)
+	}
+	return fields
+}
+
+// LoggerFrom the given context, its logs are correlated with the request and the datadog trace
+func LoggerFrom(ctx context.Context) *logrus.Entry {
+	return Logger.WithFields(LogFields(ctx)).Entry
+}
+
+// HandleErrorContext handles errors sending them to sentry and logging, correlated with the given context
+func HandleErrorContext(ctx context.Context, message string, err error) {
+	fields := LogFields(ctx)
+	fields["error"] = err
+	fields["stack"] = string(debug.Stack())
+	Logger.WithFields(fields).Error(message)
+
+	hub := sentry.GetHubFromContext(ctx)
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		for key, value := range LogFields(ctx) {
+			scope.SetTag(key, value.(string))
+		}
+		hub.CaptureException(err)
+	})
+}
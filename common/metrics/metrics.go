@@ -0,0 +1,31 @@
+// Package metrics holds the prometheus registry where every layer registers its metrics, exposed by the api in text format
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry of the application metrics, go runtime and process metrics included
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Factory to create metrics registered in the application registry
+func Factory() promauto.Factory {
+	return promauto.With(Registry)
+}
+
+// Handler serving the registry metrics in prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -2,19 +2,61 @@
 package pagination
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
 const defaultSize = 10
 
+// ErrInvalidCursor when a cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 // Pagination component
 type Pagination struct {
-	from int
-	size int
+	from      int
+	size      int
+	cursor    *cursor
+	skipCount bool
+	ascending bool
+}
+
+// Key of a result in keyset pagination
+type Key struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+type cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int       `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+func (c cursor) encode() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := cursor{}
+	if err := json.Unmarshal(bytes, &c); err != nil || c.ID == 0 || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
 }
 
 // GetFrom returns the current from value
@@ -35,18 +77,73 @@ func (p Pagination) GetPageNumber() int {
 	return 0
 }
 
+// SkipCount tells whether the total count was not requested
+func (p Pagination) SkipCount() bool {
+	return p.skipCount
+}
+
+// HasCursor tells whether the current page is selected around a cursor instead of an offset
+func (p Pagination) HasCursor() bool {
+	return p.cursor != nil
+}
+
+// OldestFirst returns the pagination ordering its pages by creation time ascending instead of newest first
+func (p Pagination) OldestFirst() Pagination {
+	p.ascending = true
+	return p
+}
+
+// Backward tells whether the current page is before its cursor, its results are then selected in reverse order
+func (p Pagination) Backward() bool {
+	return p.cursor != nil && p.cursor.Backward
+}
+
 // Response of pagination
 type Response struct {
-	Total   int         `json:"total"`
-	Results interface{} `json:"results"`
+	Total      *int        `json:"total,omitempty"`
+	Results    interface{} `json:"results"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	PrevCursor string      `json:"prevCursor,omitempty"`
 }
 
-// GetResponse returns pagination paginated response
+// GetResponse returns pagination paginated response, the total is omitted when its count was skipped
 func (p Pagination) GetResponse(total int, results interface{}) Response {
-	return Response{
-		Total:   total,
+	resp := Response{
 		Results: results,
 	}
+	if !p.skipCount {
+		resp.Total = &total
+	}
+
+	return resp
+}
+
+// GetKeysetResponse returns pagination paginated response with the cursors of the pages around the given result keys
+func (p Pagination) GetKeysetResponse(total int, results interface{}, keys []Key) Response {
+	resp := p.GetResponse(total, results)
+	if len(keys) == 0 {
+		return resp
+	}
+
+	first := cursor{CreatedAt: keys[0].CreatedAt, ID: keys[0].ID, Backward: true}
+	last := cursor{CreatedAt: keys[len(keys)-1].CreatedAt, ID: keys[len(keys)-1].ID}
+	full := len(keys) >= p.size
+	if p.Backward() {
+		resp.NextCursor = last.encode()
+		if full {
+			resp.PrevCursor = first.encode()
+		}
+		return resp
+	}
+
+	if full {
+		resp.NextCursor = last.encode()
+	}
+	if p.cursor != nil || p.from > 0 {
+		resp.PrevCursor = first.encode()
+	}
+
+	return resp
 }
 
 // New creates a pagination complex struct instance
@@ -67,6 +164,8 @@ func New(from, size int) (Pagination, error) {
 func FromRequest(r *http.Request) (Pagination, error) {
 	fromParameter := r.URL.Query().Get("from")
 	sizeParameter := r.URL.Query().Get("size")
+	cursorParameter := r.URL.Query().Get("cursor")
+	skipCountParameter := r.URL.Query().Get("skipCount")
 
 	from := 0
 	if fromParameter != "" {
@@ -86,9 +185,29 @@ func FromRequest(r *http.Request) (Pagination, error) {
 		size = s
 	}
 
+	var c *cursor
+	if cursorParameter != "" {
+		decoded, err := decodeCursor(cursorParameter)
+		if err != nil {
+			return Pagination{}, err
+		}
+		c = decoded
+	}
+
+	skipCount := false
+	if skipCountParameter != "" {
+		s, err := strconv.ParseBool(skipCountParameter)
+		if err != nil {
+			return Pagination{}, err
+		}
+		skipCount = s
+	}
+
 	p := Pagination{
-		from: from,
-		size: size,
+		from:      from,
+		size:      size,
+		cursor:    c,
+		skipCount: skipCount,
 	}
 	if err := p.validate(); err != nil {
 		return Pagination{}, err
@@ -102,9 +221,28 @@ func (p Pagination) PaginateQuery(sqlQuery string) string {
 	return fmt.Sprintf("%s LIMIT %d OFFSET %d", sqlQuery, p.size, p.from)
 }
 
+// PaginateSelect orders a select by creation time and id, newest first unless ordered oldest first,
+// restricting it to the current page, either after its cursor or at its offset
+func (p Pagination) PaginateSelect(sqq sq.SelectBuilder) sq.SelectBuilder {
+	direction, reverse, after, before := "DESC", "ASC", "<", ">"
+	if p.ascending {
+		direction, reverse, after, before = reverse, direction, before, after
+	}
+
+	sqq = sqq.Limit(uint64(p.size))
+	if p.cursor == nil {
+		return sqq.OrderBy("created_at "+direction, "id "+direction).Offset(uint64(p.from))
+	}
+	if p.cursor.Backward {
+		return sqq.Where(sq.Expr("(created_at, id) "+before+" (?, ?)", p.cursor.CreatedAt, p.cursor.ID)).OrderBy("created_at "+reverse, "id "+reverse)
+	}
+
+	return sqq.Where(sq.Expr("(created_at, id) "+after+" (?, ?)", p.cursor.CreatedAt, p.cursor.ID)).OrderBy("created_at "+direction, "id "+direction)
+}
+
 func (p Pagination) validate() error {
 	return validation.ValidateStruct(&p,
-		validation.Field(&p.from, validation.Min(0)),
+		validation.Field(&p.from, validation.Min(0), validation.When(p.cursor != nil, validation.Empty)),
 		validation.Field(&p.size, validation.Required, validation.Min(1), validation.Max(30)),
 	)
 }
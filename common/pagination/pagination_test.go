@@ -6,6 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestNew(t *testing.T) {
@@ -64,11 +68,134 @@ func TestPaginateQuery(t *testing.T) {
 	}
 }
 
+func TestPaginateSelect(t *testing.T) {
+	createdAt := time.Date(2021, 1, 6, 20, 35, 0, 0, time.UTC)
+	testCases := []struct {
+		name           string
+		pagination     Pagination
+		expectedQuery  string
+		expectedValues []interface{}
+	}{
+		{
+			name:          "offset page",
+			pagination:    Pagination{from: 90, size: 30},
+			expectedQuery: "SELECT * FROM xablau ORDER BY created_at DESC, id DESC LIMIT 30 OFFSET 90",
+		},
+		{
+			name:           "page after cursor",
+			pagination:     Pagination{size: 30, cursor: &cursor{CreatedAt: createdAt, ID: 7}},
+			expectedQuery:  "SELECT * FROM xablau WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC LIMIT 30",
+			expectedValues: []interface{}{createdAt, 7},
+		},
+		{
+			name:           "page before cursor",
+			pagination:     Pagination{size: 30, cursor: &cursor{CreatedAt: createdAt, ID: 7, Backward: true}},
+			expectedQuery:  "SELECT * FROM xablau WHERE (created_at, id) > ($1, $2) ORDER BY created_at ASC, id ASC LIMIT 30",
+			expectedValues: []interface{}{createdAt, 7},
+		},
+		{
+			name:          "oldest first offset page",
+			pagination:    Pagination{from: 90, size: 30, ascending: true},
+			expectedQuery: "SELECT * FROM xablau ORDER BY created_at ASC, id ASC LIMIT 30 OFFSET 90",
+		},
+		{
+			name:           "oldest first page after cursor",
+			pagination:     Pagination{size: 30, cursor: &cursor{CreatedAt: createdAt, ID: 7}, ascending: true},
+			expectedQuery:  "SELECT * FROM xablau WHERE (created_at, id) > ($1, $2) ORDER BY created_at ASC, id ASC LIMIT 30",
+			expectedValues: []interface{}{createdAt, 7},
+		},
+		{
+			name:           "oldest first page before cursor",
+			pagination:     Pagination{size: 30, cursor: &cursor{CreatedAt: createdAt, ID: 7, Backward: true}, ascending: true},
+			expectedQuery:  "SELECT * FROM xablau WHERE (created_at, id) < ($1, $2) ORDER BY created_at DESC, id DESC LIMIT 30",
+			expectedValues: []interface{}{createdAt, 7},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sqq := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).Select("*").From("xablau")
+			query, values, err := tc.pagination.PaginateSelect(sqq).ToSql()
+			if err != nil {
+				t.Errorf("unexpected error building paginated select %s", err)
+				return
+			}
+			if query != tc.expectedQuery {
+				t.Errorf("error on generated paginated select %s", query)
+				return
+			}
+			if diff := cmp.Diff(values, tc.expectedValues); diff != "" {
+				t.Errorf("unexpected paginated select values %s", diff)
+				return
+			}
+		})
+	}
+}
+
+func TestGetKeysetResponse(t *testing.T) {
+	createdAt := time.Date(2021, 1, 6, 20, 35, 0, 0, time.UTC)
+	keys := []Key{
+		{CreatedAt: createdAt, ID: 2},
+		{CreatedAt: createdAt.Add(-time.Minute), ID: 1},
+	}
+	total := 5
+	first := cursor{CreatedAt: keys[0].CreatedAt, ID: keys[0].ID, Backward: true}.encode()
+	last := cursor{CreatedAt: keys[1].CreatedAt, ID: keys[1].ID}.encode()
+
+	testCases := []struct {
+		name       string
+		pagination Pagination
+		keys       []Key
+		expected   Response
+	}{
+		{
+			name:       "first page",
+			pagination: Pagination{size: 2},
+			keys:       keys,
+			expected:   Response{Total: &total, NextCursor: last},
+		},
+		{
+			name:       "last offset page",
+			pagination: Pagination{from: 2, size: 3},
+			keys:       keys,
+			expected:   Response{Total: &total, PrevCursor: first},
+		},
+		{
+			name:       "page after cursor skipping count",
+			pagination: Pagination{size: 2, cursor: &cursor{CreatedAt: createdAt, ID: 3}, skipCount: true},
+			keys:       keys,
+			expected:   Response{NextCursor: last, PrevCursor: first},
+		},
+		{
+			name:       "first page before cursor",
+			pagination: Pagination{size: 3, cursor: &cursor{CreatedAt: createdAt, ID: 3, Backward: true}},
+			keys:       keys,
+			expected:   Response{Total: &total, NextCursor: last},
+		},
+		{
+			name:       "empty page",
+			pagination: Pagination{size: 2, cursor: &cursor{CreatedAt: createdAt, ID: 3}},
+			expected:   Response{Total: &total},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.pagination.GetKeysetResponse(total, nil, tc.keys)
+			if diff := cmp.Diff(result, tc.expected); diff != "" {
+				t.Errorf("unexpected keyset response %s", diff)
+				return
+			}
+		})
+	}
+}
+
 func TestFromRequest(t *testing.T) {
+	validCursor := cursor{CreatedAt: time.Date(2021, 1, 6, 20, 35, 0, 0, time.UTC), ID: 7}
 	testCases := []struct {
 		name          string
 		from          string
 		size          string
+		cursor        string
+		skipCount     string
 		expected      Pagination
 		expectedError string
 	}{
@@ -100,15 +227,42 @@ func TestFromRequest(t *testing.T) {
 			size:          "1",
 			expectedError: `strconv.Atoi: parsing "abc": invalid syntax`,
 		},
+		{
+			name:      "valid cursor request",
+			size:      "20",
+			cursor:    validCursor.encode(),
+			skipCount: "true",
+			expected: Pagination{
+				size:      20,
+				cursor:    &validCursor,
+				skipCount: true,
+			},
+		},
+		{
+			name:          "invalid cursor",
+			cursor:        "abc",
+			expectedError: "invalid cursor",
+		},
+		{
+			name:          "from with cursor",
+			from:          "10",
+			cursor:        validCursor.encode(),
+			expectedError: "from: must be blank.",
+		},
+		{
+			name:          "invalid skip count",
+			skipCount:     "abc",
+			expectedError: `strconv.ParseBool: parsing "abc": invalid syntax`,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/xablau?from=%s&size=%s", tc.from, tc.size), nil)
+			request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/xablau?from=%s&size=%s&cursor=%s&skipCount=%s", tc.from, tc.size, tc.cursor, tc.skipCount), nil)
 			p, err := FromRequest(request)
 			test.AssertError(t, err, tc.expectedError)
 
-			if p.size != tc.expected.size || p.from != tc.expected.from {
-				t.Errorf("unexptected pagination %+v", p)
+			if diff := cmp.Diff(p, tc.expected, cmp.AllowUnexported(Pagination{})); diff != "" {
+				t.Errorf("unexptected pagination %s", diff)
 			}
 		})
 	}
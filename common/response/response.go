@@ -2,6 +2,7 @@
 package response
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"go-boilerplate/common"
@@ -17,18 +18,57 @@ const (
 	unprocessableEntityCode = "GEN002"
 	unauthorizedErrorCode   = "GEN003"
 	forbiddenErrorCode      = "GEN004"
+	notFoundErrorCode       = "GEN005"
+	conflictErrorCode       = "GEN006"
+	preconditionFailedCode  = "GEN007"
+	tooManyRequestsCode     = "GEN008"
 
 	validationErrorCode = "VLD001"
 )
 
-var errorCodes = [...]errorCode{}
+var errorCodes = [...]errorCode{
+	{
+		err:    repository.ErrNotFound,
+		code:   notFoundErrorCode,
+		status: http.StatusNotFound,
+	},
+	{
+		err:    repository.ErrUnauthorizedResource,
+		code:   forbiddenErrorCode,
+		status: http.StatusForbidden,
+	},
+	{
+		err:    repository.ErrUnprocessableEntityResource,
+		code:   unprocessableEntityCode,
+		status: http.StatusUnprocessableEntity,
+	},
+	{
+		err:    repository.ErrPreconditionFailed,
+		code:   preconditionFailedCode,
+		status: http.StatusPreconditionFailed,
+	},
+	{
+		is:     repository.IsUniqueConstraintViolation,
+		code:   conflictErrorCode,
+		status: http.StatusConflict,
+	},
+}
 
+// errorCode maps an error, by its sentinel value or a custom matcher, to an API error code and status
 type errorCode struct {
 	err    error
+	is     func(error) bool
 	code   string
 	status int
 }
 
+func (e errorCode) matches(err error) bool {
+	if e.is != nil {
+		return e.is(err)
+	}
+	return errors.Is(err, e.err)
+}
+
 // Error is the default API error format
 type Error struct {
 	Code  string `json:"code"`
@@ -40,8 +80,30 @@ type Success struct {
 	ID int `json:"id,omitempty"`
 }
 
-// Write writes needed headers and content to response
-func Write(w http.ResponseWriter, body interface{}, status int) {
+// BulkItem is the result of an item of a bulk request, either its ID or its error
+type BulkItem struct {
+	Status int    `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Bulk is the default API bulk format, with the results of the items in request order
+type Bulk struct {
+	Items []BulkItem `json:"items"`
+}
+
+// Write writes needed headers and content to response, a body failing to be marshaled is reported correlated with the request
+func Write(w http.ResponseWriter, r *http.Request, body interface{}, status int) {
+	write(r.Context(), w, body, status)
+}
+
+// writeError writes the given error body, which always marshals so there's nothing to correlate with the request
+func writeError(w http.ResponseWriter, body Error, status int) {
+	write(context.Background(), w, body, status)
+}
+
+func write(ctx context.Context, w http.ResponseWriter, body interface{}, status int) {
 	if body == nil {
 		w.WriteHeader(status)
 		return
@@ -49,7 +111,7 @@ func Write(w http.ResponseWriter, body interface{}, status int) {
 	bytes, err := json.Marshal(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		common.HandleError("error marshaling json body", err)
+		common.HandleErrorContext(ctx, "error marshaling json body", err)
 		return
 	}
 
@@ -58,10 +120,10 @@ func Write(w http.ResponseWriter, body interface{}, status int) {
 	w.Write(bytes)
 }
 
-// WriteServerError writes the given error to response
-func WriteServerError(w http.ResponseWriter, err error, message string) {
-	common.HandleError(message, err)
-	Write(w, Error{
+// WriteServerError writes the given error to response, reporting it correlated with the request
+func WriteServerError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	common.HandleErrorContext(r.Context(), message, err)
+	Write(w, r, Error{
 		Code:  unknownErrorCode,
 		Error: err.Error(),
 	}, http.StatusInternalServerError)
@@ -77,20 +139,48 @@ func WriteError(w http.ResponseWriter, r *http.Request, err error, message strin
 		}
 	}
 	for _, errorCode := range errorCodes {
-		if errors.Is(err, errorCode.err) {
-			Write(w, Error{
+		if errorCode.matches(err) {
+			Write(w, r, Error{
 				Code:  errorCode.code,
 				Error: err.Error(),
 			}, errorCode.status)
 			return
 		}
 	}
-	WriteServerError(w, err, message)
+	WriteServerError(w, r, err, message)
+}
+
+// BulkItemError of a failed item of a bulk request, unknown errors are reported correlated with the request
+func BulkItemError(r *http.Request, err error, message string) BulkItem {
+	for _, errorCode := range errorCodes {
+		if errorCode.matches(err) {
+			return BulkItem{
+				Status: errorCode.status,
+				Code:   errorCode.code,
+				Error:  err.Error(),
+			}
+		}
+	}
+	common.HandleErrorContext(r.Context(), message, err)
+	return BulkItem{
+		Status: http.StatusInternalServerError,
+		Code:   unknownErrorCode,
+		Error:  err.Error(),
+	}
+}
+
+// BulkItemValidationError of an invalid item of a bulk request
+func BulkItemValidationError(err error) BulkItem {
+	return BulkItem{
+		Status: http.StatusBadRequest,
+		Code:   validationErrorCode,
+		Error:  err.Error(),
+	}
 }
 
 // WriteUnauthorizedError writes the given error to response
 func WriteUnauthorizedError(w http.ResponseWriter) {
-	Write(w, Error{
+	writeError(w, Error{
 		Code:  unauthorizedErrorCode,
 		Error: http.StatusText(http.StatusUnauthorized),
 	}, http.StatusUnauthorized)
@@ -98,15 +188,23 @@ func WriteUnauthorizedError(w http.ResponseWriter) {
 
 // WriteForbiddenError writes the given error to response
 func WriteForbiddenError(w http.ResponseWriter) {
-	Write(w, Error{
+	writeError(w, Error{
 		Code:  forbiddenErrorCode,
 		Error: http.StatusText(http.StatusForbidden),
 	}, http.StatusForbidden)
 }
 
+// WriteTooManyRequestsError writes a rate limited response
+func WriteTooManyRequestsError(w http.ResponseWriter) {
+	writeError(w, Error{
+		Code:  tooManyRequestsCode,
+		Error: http.StatusText(http.StatusTooManyRequests),
+	}, http.StatusTooManyRequests)
+}
+
 // WriteUnprocessableEntity writes a unprocessable entity response
 func WriteUnprocessableEntity(w http.ResponseWriter, err error) {
-	Write(w, Error{
+	writeError(w, Error{
 		Code:  unprocessableEntityCode,
 		Error: err.Error(),
 	}, http.StatusUnprocessableEntity)
@@ -114,7 +212,7 @@ func WriteUnprocessableEntity(w http.ResponseWriter, err error) {
 
 // WriteValidationError writes a vlidation error to response
 func WriteValidationError(w http.ResponseWriter, err error) {
-	Write(w, Error{
+	writeError(w, Error{
 		Code:  validationErrorCode,
 		Error: err.Error(),
 	}, http.StatusBadRequest)
@@ -2,10 +2,14 @@ package response_test
 
 import (
 	"errors"
+	"fmt"
 	"go-boilerplate/common/response"
+	"go-boilerplate/repository"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/jackc/pgconn"
 )
 
 func TestWrite(t *testing.T) {
@@ -17,7 +21,7 @@ func TestWrite(t *testing.T) {
 		Y: 2,
 	}
 	rw := httptest.NewRecorder()
-	response.Write(rw, body, http.StatusOK)
+	response.Write(rw, httptest.NewRequest(http.MethodGet, "/", nil), body, http.StatusOK)
 	rw.Flush()
 	if rw.Code != http.StatusOK {
 		t.Errorf("unexpected status code %d", rw.Code)
@@ -43,7 +47,42 @@ func TestWriteError(t *testing.T) {
 			err:            errors.New("timeout bla blabla"),
 			message:        "error message",
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"code":"CAS001","error":"timeout bla blabla"}`,
+			expectedBody:   `{"code":"GEN001","error":"timeout bla blabla"}`,
+		},
+		{
+			name:           "not found error",
+			err:            fmt.Errorf("comment 1: %w", repository.ErrNotFound),
+			message:        "error message",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":"GEN005","error":"comment 1: resource not found"}`,
+		},
+		{
+			name:           "unauthorized resource error",
+			err:            repository.ErrUnauthorizedResource,
+			message:        "error message",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"code":"GEN004","error":"unauthorized resource"}`,
+		},
+		{
+			name:           "unprocessable entity resource error",
+			err:            repository.ErrUnprocessableEntityResource,
+			message:        "error message",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"code":"GEN002","error":"unprocessable request"}`,
+		},
+		{
+			name:           "precondition failed error",
+			err:            fmt.Errorf("comment 1: %w", repository.ErrPreconditionFailed),
+			message:        "error message",
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"code":"GEN007","error":"comment 1: resource version mismatch"}`,
+		},
+		{
+			name:           "unique violation error",
+			err:            &pgconn.PgError{Severity: "ERROR", Code: "23505", Message: "duplicate key value violates unique constraint"},
+			message:        "error message",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"code":"GEN006","error":"ERROR: duplicate key value violates unique constraint (SQLSTATE 23505)"}`,
 		},
 	}
 	for _, tc := range testCases {
@@ -64,3 +103,37 @@ func TestWriteError(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkItemError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected response.BulkItem
+	}{
+		{
+			name:     "unknown error",
+			err:      errors.New("timeout bla blabla"),
+			expected: response.BulkItem{Status: http.StatusInternalServerError, Code: "GEN001", Error: "timeout bla blabla"},
+		},
+		{
+			name:     "not found error",
+			err:      fmt.Errorf("comment 1: %w", repository.ErrNotFound),
+			expected: response.BulkItem{Status: http.StatusNotFound, Code: "GEN005", Error: "comment 1: resource not found"},
+		},
+		{
+			name:     "unauthorized resource error",
+			err:      repository.ErrUnauthorizedResource,
+			expected: response.BulkItem{Status: http.StatusForbidden, Code: "GEN004", Error: "unauthorized resource"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "http://test.com.br/v1/test/bulk", nil)
+			result := response.BulkItemError(r, tc.err, "error message")
+
+			if result != tc.expected {
+				t.Errorf("unexpected bulk item %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package advertiser holds advertisers configuration in the portals where they publish their listings
+package advertiser
+
+import (
+	"errors"
+	"go-boilerplate/domain"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+var (
+	errDuplicatedPortal = errors.New("portals must be unique")
+	errAdvertiserKey    = errors.New("advertisers must be keyed by their advertiserId")
+)
+
+// PortalSettings of an advertiser in a portal
+type PortalSettings struct {
+	Portal  domain.ListingOrigin `json:"portal"`
+	Enabled bool                 `json:"enabled"`
+	// ContactEmail receives the leads of the portal, the advertiser account email is used when it's blank
+	ContactEmail string `json:"contactEmail,omitempty"`
+	// WebhookURL is notified of the leads of the portal when it's set
+	WebhookURL string `json:"webhookUrl,omitempty"`
+}
+
+// Validate the given portal settings
+func (s PortalSettings) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Portal, validation.Required),
+		validation.Field(&s.ContactEmail, is.EmailFormat),
+		validation.Field(&s.WebhookURL, is.RequestURL),
+	)
+}
+
+// Config of an advertiser in the portals
+type Config struct {
+	AdvertiserID string           `json:"advertiserId"`
+	Portals      []PortalSettings `json:"portals"`
+}
+
+// Validate the given advertiser config
+func (c Config) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.AdvertiserID, validation.Required, is.UUID),
+		validation.Field(&c.Portals, validation.Required, validation.By(uniquePortals)),
+	)
+}
+
+// Portal settings of the config, not found when the advertiser isn't configured in the given portal
+func (c Config) Portal(portal domain.ListingOrigin) (PortalSettings, bool) {
+	for _, settings := range c.Portals {
+		if settings.Portal == portal {
+			return settings, true
+		}
+	}
+	return PortalSettings{}, false
+}
+
+func uniquePortals(value interface{}) error {
+	seen := map[domain.ListingOrigin]bool{}
+	for _, settings := range value.([]PortalSettings) {
+		if seen[settings.Portal] {
+			return errDuplicatedPortal
+		}
+		seen[settings.Portal] = true
+	}
+	return nil
+}
+
+// Configs of all advertisers kept as a single file, every update creates a new version of it
+type Configs struct {
+	Version     int               `json:"version"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+	UpdatedBy   string            `json:"updatedBy"`
+	Advertisers map[string]Config `json:"advertisers"`
+}
+
+// Validate the given configs, advertisers must be keyed by their IDs
+func (c Configs) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Version, validation.Min(0)),
+		validation.Field(&c.Advertisers, validation.By(keyedByAdvertiserID)),
+	)
+}
+
+func keyedByAdvertiserID(value interface{}) error {
+	for ID, cfg := range value.(map[string]Config) {
+		if ID != cfg.AdvertiserID {
+			return errAdvertiserKey
+		}
+	}
+	return nil
+}
+
+// With returns a new version of the configs with the given advertiser config updated by the given account
+func (c Configs) With(cfg Config, updatedBy string, now time.Time) Configs {
+	advertisers := make(map[string]Config, len(c.Advertisers)+1)
+	for ID, current := range c.Advertisers {
+		advertisers[ID] = current
+	}
+	advertisers[cfg.AdvertiserID] = cfg
+
+	return Configs{
+		Version:     c.Version + 1,
+		UpdatedAt:   now,
+		UpdatedBy:   updatedBy,
+		Advertisers: advertisers,
+	}
+}
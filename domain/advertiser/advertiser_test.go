@@ -0,0 +1,135 @@
+package advertiser_test
+
+import (
+	"go-boilerplate/domain"
+	"go-boilerplate/domain/advertiser"
+	"go-boilerplate/test"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	advertiserID := gofakeit.UUID()
+	testCases := []struct {
+		name          string
+		config        advertiser.Config
+		expectedError string
+	}{
+		{
+			name: "valid config",
+			config: advertiser.Config{
+				AdvertiserID: advertiserID,
+				Portals: []advertiser.PortalSettings{
+					{Portal: domain.PortalVivaReal, Enabled: true, ContactEmail: gofakeit.Email()},
+					{Portal: domain.PortalZap, WebhookURL: "https://crm.example.com/leads"},
+				},
+			},
+		},
+		{
+			name:          "missing fields",
+			config:        advertiser.Config{},
+			expectedError: "advertiserId: cannot be blank; portals: cannot be blank.",
+		},
+		{
+			name: "invalid portal settings",
+			config: advertiser.Config{
+				AdvertiserID: advertiserID,
+				Portals: []advertiser.PortalSettings{
+					{ContactEmail: "invalid", WebhookURL: "invalid"},
+				},
+			},
+			expectedError: "portals: (0: (contactEmail: must be a valid email address; portal: cannot be blank; webhookUrl: must be a valid request URL.).).",
+		},
+		{
+			name: "duplicated portal",
+			config: advertiser.Config{
+				AdvertiserID: advertiserID,
+				Portals: []advertiser.PortalSettings{
+					{Portal: domain.PortalZap},
+					{Portal: domain.PortalZap, Enabled: true},
+				},
+			},
+			expectedError: "portals: portals must be unique.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestValidateConfigs(t *testing.T) {
+	cfg := advertiser.Config{
+		AdvertiserID: gofakeit.UUID(),
+		Portals:      []advertiser.PortalSettings{{Portal: domain.PortalZap}},
+	}
+	testCases := []struct {
+		name          string
+		configs       advertiser.Configs
+		expectedError string
+	}{
+		{
+			name:    "empty configs",
+			configs: advertiser.Configs{},
+		},
+		{
+			name: "valid configs",
+			configs: advertiser.Configs{
+				Version:     1,
+				Advertisers: map[string]advertiser.Config{cfg.AdvertiserID: cfg},
+			},
+		},
+		{
+			name: "advertiser keyed by another id",
+			configs: advertiser.Configs{
+				Version:     1,
+				Advertisers: map[string]advertiser.Config{gofakeit.UUID(): cfg},
+			},
+			expectedError: "advertisers: advertisers must be keyed by their advertiserId.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.configs.Validate()
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestWith(t *testing.T) {
+	now := time.Now()
+	current := advertiser.Config{
+		AdvertiserID: gofakeit.UUID(),
+		Portals:      []advertiser.PortalSettings{{Portal: domain.PortalZap}},
+	}
+	updated := advertiser.Config{
+		AdvertiserID: gofakeit.UUID(),
+		Portals:      []advertiser.PortalSettings{{Portal: domain.PortalVivaReal, Enabled: true}},
+	}
+	configs := advertiser.Configs{
+		Version:     3,
+		Advertisers: map[string]advertiser.Config{current.AdvertiserID: current},
+	}
+
+	result := configs.With(updated, "admin", now)
+	expected := advertiser.Configs{
+		Version:   4,
+		UpdatedAt: now,
+		UpdatedBy: "admin",
+		Advertisers: map[string]advertiser.Config{
+			current.AdvertiserID: current,
+			updated.AdvertiserID: updated,
+		},
+	}
+	if diff := cmp.Diff(result, expected); diff != "" {
+		t.Errorf("unexpected updated configs %s", diff)
+	}
+	if len(configs.Advertisers) != 1 {
+		t.Errorf("unexpected change of the previous configs version")
+	}
+}
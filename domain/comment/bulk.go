@@ -0,0 +1,158 @@
+package comment
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-boilerplate/common"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// BulkMaxOperations of a bulk request
+var BulkMaxOperations = common.Config.GetInt("commentBulkMaxOperations")
+
+// BulkMode of a bulk request, how failed operations affect the others
+type BulkMode int
+
+const (
+	// BulkModeNone zero value for this enum
+	BulkModeNone BulkMode = iota
+	// BulkAllOrNothing executes all operations in a single transaction, rolled back by the first failed one
+	BulkAllOrNothing
+	// BulkPartial executes operations independently, failed ones don't affect the others
+	BulkPartial
+)
+
+var bulkModeValues = [...]string{
+	"",
+	"ALL_OR_NOTHING",
+	"PARTIAL",
+}
+
+func (m BulkMode) String() string {
+	return bulkModeValues[m]
+}
+
+// MarshalJSON marshals the enum as a quoted json string
+func (m BulkMode) MarshalJSON() ([]byte, error) {
+	return common.QuotedStringBytes(m.String()), nil
+}
+
+// UnmarshalJSON unmarshals a quoted json string to the enum value
+func (m *BulkMode) UnmarshalJSON(b []byte) error {
+	x := ""
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+	value, err := BulkModeValueOf(x)
+	if err != nil {
+		return err
+	}
+	*m = value
+	return nil
+}
+
+// BulkModeValueOf converts a bulk mode value into a bulk mode
+func BulkModeValueOf(v string) (BulkMode, error) {
+	for i, value := range bulkModeValues {
+		if value == v {
+			return BulkMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown bulk mode value %s", v)
+}
+
+// BulkAction of an operation of a bulk request
+type BulkAction int
+
+const (
+	// BulkActionNone zero value for this enum
+	BulkActionNone BulkAction = iota
+	// BulkCreate creates a comment
+	BulkCreate
+	// BulkUpdate updates a comment
+	BulkUpdate
+	// BulkDelete soft deletes a comment
+	BulkDelete
+)
+
+var bulkActionValues = [...]string{
+	"",
+	"CREATE",
+	"UPDATE",
+	"DELETE",
+}
+
+func (a BulkAction) String() string {
+	return bulkActionValues[a]
+}
+
+// MarshalJSON marshals the enum as a quoted json string
+func (a BulkAction) MarshalJSON() ([]byte, error) {
+	return common.QuotedStringBytes(a.String()), nil
+}
+
+// UnmarshalJSON unmarshals a quoted json string to the enum value
+func (a *BulkAction) UnmarshalJSON(b []byte) error {
+	x := ""
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+	value, err := BulkActionValueOf(x)
+	if err != nil {
+		return err
+	}
+	*a = value
+	return nil
+}
+
+// BulkActionValueOf converts a bulk action value into a bulk action
+func BulkActionValueOf(v string) (BulkAction, error) {
+	for i, value := range bulkActionValues {
+		if value == v {
+			return BulkAction(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown bulk action value %s", v)
+}
+
+// BulkOperation of a bulk request, updates and deletes target the comment of the given ID,
+// checking its version when it is set
+type BulkOperation struct {
+	Action  BulkAction `json:"action"`
+	ID      int        `json:"id,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Comment *Comment   `json:"comment,omitempty"`
+}
+
+// Validate the given bulk operation, comments are required by creates and updates
+func (o BulkOperation) Validate() error {
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.Action, validation.Required),
+		validation.Field(&o.ID, validation.When(o.Action == BulkCreate, validation.Empty).Else(validation.Required, validation.Min(1))),
+		validation.Field(&o.Version, validation.Min(0)),
+		validation.Field(&o.Comment, validation.When(o.Action == BulkDelete, validation.Nil).Else(validation.Required)),
+	)
+}
+
+// Bulk request of comment operations
+type Bulk struct {
+	Mode       BulkMode        `json:"mode"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// Validate the given bulk request, its operations are validated one by one so the partial mode can report each of them
+func (b Bulk) Validate() error {
+	return validation.ValidateStruct(&b,
+		validation.Field(&b.Mode, validation.Required),
+		validation.Field(&b.Operations, validation.Required, validation.Length(1, BulkMaxOperations), validation.Skip),
+	)
+}
+
+// BulkResult of a bulk operation, either the ID of its comment or its error
+type BulkResult struct {
+	ID  int
+	Err error
+}
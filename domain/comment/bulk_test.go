@@ -0,0 +1,150 @@
+package comment_test
+
+import (
+	"encoding/json"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/test"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBulkOperationValidate(t *testing.T) {
+	cmt := &comment.Comment{
+		Type:         comment.Lead,
+		Description:  gofakeit.HackerPhrase(),
+		AdvertiserID: gofakeit.UUID(),
+		AccountID:    gofakeit.UUID(),
+		ListingID:    gofakeit.Numerify("##########"),
+		Owner: comment.Owner{
+			Name:      gofakeit.Name(),
+			Email:     gofakeit.Email(),
+			AccountID: gofakeit.UUID(),
+		},
+	}
+	invalid := *cmt
+	invalid.Description = ""
+
+	testCases := []struct {
+		name          string
+		operation     comment.BulkOperation
+		expectedError string
+	}{
+		{
+			name:      "valid create",
+			operation: comment.BulkOperation{Action: comment.BulkCreate, Comment: cmt},
+		},
+		{
+			name:      "valid update",
+			operation: comment.BulkOperation{Action: comment.BulkUpdate, ID: 1, Version: 2, Comment: cmt},
+		},
+		{
+			name:      "valid delete",
+			operation: comment.BulkOperation{Action: comment.BulkDelete, ID: 1},
+		},
+		{
+			name:          "missing action",
+			operation:     comment.BulkOperation{Comment: cmt},
+			expectedError: "action: cannot be blank; id: cannot be blank.",
+		},
+		{
+			name:          "create with id",
+			operation:     comment.BulkOperation{Action: comment.BulkCreate, ID: 1, Comment: cmt},
+			expectedError: "id: must be blank.",
+		},
+		{
+			name:          "create without comment",
+			operation:     comment.BulkOperation{Action: comment.BulkCreate},
+			expectedError: "comment: cannot be blank.",
+		},
+		{
+			name:          "create of invalid comment",
+			operation:     comment.BulkOperation{Action: comment.BulkCreate, Comment: &invalid},
+			expectedError: "comment: (description: cannot be blank.).",
+		},
+		{
+			name:          "update without id",
+			operation:     comment.BulkOperation{Action: comment.BulkUpdate, Comment: cmt},
+			expectedError: "id: cannot be blank.",
+		},
+		{
+			name:          "delete with comment",
+			operation:     comment.BulkOperation{Action: comment.BulkDelete, ID: 1, Comment: cmt},
+			expectedError: "comment: must be blank.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.operation.Validate()
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestBulkValidate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		bulk          comment.Bulk
+		expectedError string
+	}{
+		{
+			name: "valid bulk",
+			bulk: comment.Bulk{
+				Mode:       comment.BulkPartial,
+				Operations: []comment.BulkOperation{{Action: comment.BulkDelete, ID: 1}},
+			},
+		},
+		{
+			name: "operations aren't validated",
+			bulk: comment.Bulk{
+				Mode:       comment.BulkAllOrNothing,
+				Operations: []comment.BulkOperation{{}},
+			},
+		},
+		{
+			name: "missing mode",
+			bulk: comment.Bulk{
+				Operations: []comment.BulkOperation{{Action: comment.BulkDelete, ID: 1}},
+			},
+			expectedError: "mode: cannot be blank.",
+		},
+		{
+			name:          "missing operations",
+			bulk:          comment.Bulk{Mode: comment.BulkPartial},
+			expectedError: "operations: cannot be blank.",
+		},
+		{
+			name: "too many operations",
+			bulk: comment.Bulk{
+				Mode:       comment.BulkPartial,
+				Operations: make([]comment.BulkOperation, comment.BulkMaxOperations+1),
+			},
+			expectedError: "operations: the length must be between 1 and 500.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.bulk.Validate()
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestBulkUnmarshal(t *testing.T) {
+	body := `{"mode":"PARTIAL","operations":[{"action":"DELETE","id":1,"version":2}]}`
+	expected := comment.Bulk{
+		Mode:       comment.BulkPartial,
+		Operations: []comment.BulkOperation{{Action: comment.BulkDelete, ID: 1, Version: 2}},
+	}
+
+	result := comment.Bulk{}
+	err := json.Unmarshal([]byte(body), &result)
+	test.AssertError(t, err, "")
+	if diff := cmp.Diff(result, expected); diff != "" {
+		t.Errorf("unexpected bulk %s", diff)
+	}
+
+	err = json.Unmarshal([]byte(`{"mode":"SOME"}`), &result)
+	test.AssertError(t, err, "unknown bulk mode value SOME")
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-boilerplate/common"
+	"go-boilerplate/domain"
+	"go-boilerplate/domain/outbox"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -72,17 +74,71 @@ func TypeValueOf(v string) (Type, error) {
 	return 0, fmt.Errorf("unknown comment type value %s", v)
 }
 
+// Owner is who wrote a comment
+type Owner struct {
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	AccountID string          `json:"accountId"`
+	Role      domain.RoleType `json:"role,omitempty"`
+}
+
+// Validate the given owner
+func (o Owner) Validate() error {
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.Name, validation.Required),
+		validation.Field(&o.Email, validation.Required, is.EmailFormat),
+		validation.Field(&o.AccountID, validation.Required, is.UUID),
+		validation.Field(&o.Role, validation.In(domain.ContactRole, domain.AdvertiserRole, domain.ProposerRole)),
+	)
+}
+
+// MaxDepth of nested replies, root comments have depth zero
+const MaxDepth = 3
+
 // Comment done by a user about some entity
 type Comment struct {
-	ID           int       `json:"id"`
-	Type         Type      `json:"type"`
-	Description  string    `json:"description"`
-	AdvertiserID string    `json:"advertiserId"`
-	AccountID    string    `json:"accountId"`
-	ListingID    string    `json:"listingId"`
-	Updated      bool      `json:"updated"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID           int        `json:"id"`
+	Type         Type       `json:"type"`
+	Description  string     `json:"description"`
+	AdvertiserID string     `json:"advertiserId"`
+	AccountID    string     `json:"accountId"`
+	ListingID    string     `json:"listingId"`
+	Updated      bool       `json:"updated"`
+	Owner        Owner      `json:"owner"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy    string     `json:"deletedBy,omitempty"`
+	Version      int        `json:"-"`
+	// ParentID of the comment this one replies to
+	ParentID *int `json:"parentId,omitempty"`
+	// Depth of the reply in its thread
+	Depth int `json:"-"`
+	// ReplyCount of active direct replies, only filled when listing comments
+	ReplyCount int `json:"replyCount,omitempty"`
+}
+
+// Deleted tells if the comment was soft deleted
+func (c Comment) Deleted() bool {
+	return c.DeletedAt != nil
+}
+
+// Tombstone of a soft deleted comment kept in its thread because of its replies, without its content and owner
+func (c Comment) Tombstone() Comment {
+	return Comment{
+		ID:           c.ID,
+		Type:         c.Type,
+		AdvertiserID: c.AdvertiserID,
+		AccountID:    c.AccountID,
+		ListingID:    c.ListingID,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+		DeletedAt:    c.DeletedAt,
+		Version:      c.Version,
+		ParentID:     c.ParentID,
+		Depth:        c.Depth,
+		ReplyCount:   c.ReplyCount,
+	}
 }
 
 // Validate the given comment
@@ -93,5 +149,185 @@ func (c Comment) Validate() error {
 		validation.Field(&c.AdvertiserID, validation.Required, is.UUID),
 		validation.Field(&c.AccountID, validation.Required, is.UUID),
 		validation.Field(&c.ListingID, validation.Required, is.Digit),
+		validation.Field(&c.Owner),
+		validation.Field(&c.ParentID, validation.NilOrNotEmpty, validation.Min(1)),
+	)
+}
+
+// Import of a comment done in another CRM system, identified there by its external ID
+type Import struct {
+	// Source is the name of the CRM system the comment comes from
+	Source     string  `json:"source"`
+	ExternalID string  `json:"externalId"`
+	Comment    Comment `json:"comment"`
+}
+
+// Validate the given comment import
+func (i Import) Validate() error {
+	return validation.ValidateStruct(&i,
+		validation.Field(&i.Source, validation.Required, validation.RuneLength(1, 50)),
+		validation.Field(&i.ExternalID, validation.Required, validation.RuneLength(1, 255)),
+		validation.Field(&i.Comment),
+	)
+}
+
+// RevisionAction possible actions that record a comment revision
+type RevisionAction int
+
+const (
+	// RevisionActionNone zero value for this enum
+	RevisionActionNone RevisionAction = iota
+	// RevisionUpdate when the comment was updated
+	RevisionUpdate
+	// RevisionDelete when the comment was deleted
+	RevisionDelete
+	// RevisionRestore when the comment was restored after being deleted
+	RevisionRestore
+)
+
+var revisionActionValues = [...]string{
+	"",
+	"UPDATE",
+	"DELETE",
+	"RESTORE",
+}
+
+func (a RevisionAction) String() string {
+	return revisionActionValues[a]
+}
+
+// MarshalJSON marshals the enum as a quoted json string
+func (a RevisionAction) MarshalJSON() ([]byte, error) {
+	return common.QuotedStringBytes(a.String()), nil
+}
+
+// UnmarshalJSON unmarshals a quoted json string to the enum value
+func (a *RevisionAction) UnmarshalJSON(b []byte) error {
+	x := ""
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+	value, err := RevisionActionValueOf(x)
+	if err != nil {
+		return err
+	}
+	*a = value
+	return nil
+}
+
+// RevisionActionValueOf converts a revision action value into a revision action
+func RevisionActionValueOf(v string) (RevisionAction, error) {
+	for i, value := range revisionActionValues {
+		if value == v {
+			return RevisionAction(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown revision action value %s", v)
+}
+
+// Revision is the state of a comment before it was changed by someone
+type Revision struct {
+	ID          int            `json:"id"`
+	CommentID   int            `json:"commentId"`
+	Action      RevisionAction `json:"action"`
+	Type        Type           `json:"type"`
+	Description string         `json:"description"`
+	ChangedBy   string         `json:"changedBy"`
+	CreatedAt   time.Time      `json:"createdAt"`
+}
+
+// NewRevision records the current state of the given comment before the given action is performed by the given account
+func NewRevision(cmt Comment, action RevisionAction, changedBy string) Revision {
+	return Revision{
+		CommentID:   cmt.ID,
+		Action:      action,
+		Type:        cmt.Type,
+		Description: cmt.Description,
+		ChangedBy:   changedBy,
+	}
+}
+
+// SearchResult is a comment matching a full-text search, ranked by relevance
+type SearchResult struct {
+	Comment
+	Rank float64 `json:"rank"`
+	// Highlight is a snippet of the HTML-escaped description with the matching terms wrapped in <mark> tags
+	Highlight string `json:"highlight"`
+}
+
+// Aggregate name of comment events
+const Aggregate = "comment"
+
+// EventType of a comment change published to other systems
+type EventType int
+
+const (
+	// EventTypeNone zero value for this enum
+	EventTypeNone EventType = iota
+	// Created when the comment was inserted
+	Created
+	// Updated when the comment was updated
+	Updated
+	// Deleted when the comment was soft deleted
+	Deleted
+)
+
+var eventTypeValues = [...]string{
+	"",
+	"CommentCreated",
+	"CommentUpdated",
+	"CommentDeleted",
+}
+
+func (t EventType) String() string {
+	return eventTypeValues[t]
+}
+
+// NewEvent records the state of the given comment after it was changed
+func NewEvent(cmt Comment, eventType EventType) (outbox.Event, error) {
+	payload, err := json.Marshal(cmt)
+	if err != nil {
+		return outbox.Event{}, err
+	}
+
+	return outbox.Event{
+		Aggregate:   Aggregate,
+		AggregateID: cmt.ID,
+		Type:        eventType.String(),
+		Payload:     payload,
+	}, nil
+}
+
+// MaxAttachmentSize in bytes
+const MaxAttachmentSize = 10 << 20
+
+var attachmentContentTypes = []interface{}{
+	"application/pdf",
+	"image/jpeg",
+	"image/png",
+	"image/heic",
+}
+
+// Attachment is a file attached to a comment, such as a contract, an ID or a photo, its content is kept in the storage
+type Attachment struct {
+	ID          int       `json:"id"`
+	CommentID   int       `json:"commentId"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Key         string    `json:"-"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// DownloadURL grants temporary access to the attachment content without authentication
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+// Validate the given attachment
+func (a Attachment) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.FileName, validation.Required, validation.RuneLength(1, 255)),
+		validation.Field(&a.ContentType, validation.Required, validation.In(attachmentContentTypes...)),
+		validation.Field(&a.Size, validation.Required, validation.Min(int64(1)), validation.Max(int64(MaxAttachmentSize))),
 	)
 }
@@ -1,14 +1,25 @@
 package comment_test
 
 import (
+	"go-boilerplate/domain"
 	"go-boilerplate/domain/comment"
 	"go-boilerplate/test"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestValidate(t *testing.T) {
+	owner := comment.Owner{
+		Name:      gofakeit.Name(),
+		Email:     gofakeit.Email(),
+		AccountID: gofakeit.UUID(),
+	}
+
+	invalidParentID := -1
+
 	testCases := []struct {
 		name          string
 		comment       comment.Comment
@@ -22,6 +33,7 @@ func TestValidate(t *testing.T) {
 				AdvertiserID: gofakeit.UUID(),
 				AccountID:    gofakeit.UUID(),
 				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
 			},
 		},
 		{
@@ -31,6 +43,7 @@ func TestValidate(t *testing.T) {
 				AdvertiserID: gofakeit.UUID(),
 				AccountID:    gofakeit.UUID(),
 				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
 			},
 			expectedError: "type: cannot be blank.",
 		},
@@ -41,6 +54,7 @@ func TestValidate(t *testing.T) {
 				AdvertiserID: gofakeit.UUID(),
 				AccountID:    gofakeit.UUID(),
 				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
 			},
 			expectedError: "description: cannot be blank.",
 		},
@@ -51,6 +65,7 @@ func TestValidate(t *testing.T) {
 				Description: gofakeit.HackerPhrase(),
 				AccountID:   gofakeit.UUID(),
 				ListingID:   gofakeit.Numerify("##########"),
+				Owner:       owner,
 			},
 			expectedError: "advertiserId: cannot be blank.",
 		},
@@ -62,6 +77,7 @@ func TestValidate(t *testing.T) {
 				AdvertiserID: gofakeit.Fruit(),
 				AccountID:    gofakeit.UUID(),
 				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
 			},
 			expectedError: "advertiserId: must be a valid UUID.",
 		},
@@ -72,6 +88,7 @@ func TestValidate(t *testing.T) {
 				Description:  gofakeit.HackerPhrase(),
 				AdvertiserID: gofakeit.UUID(),
 				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
 			},
 			expectedError: "accountId: cannot be blank.",
 		},
@@ -83,6 +100,7 @@ func TestValidate(t *testing.T) {
 				AdvertiserID: gofakeit.UUID(),
 				AccountID:    gofakeit.Animal(),
 				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
 			},
 			expectedError: "accountId: must be a valid UUID.",
 		},
@@ -93,6 +111,7 @@ func TestValidate(t *testing.T) {
 				Description:  gofakeit.HackerPhrase(),
 				AdvertiserID: gofakeit.UUID(),
 				AccountID:    gofakeit.UUID(),
+				Owner:        owner,
 			},
 			expectedError: "listingId: cannot be blank.",
 		},
@@ -104,9 +123,23 @@ func TestValidate(t *testing.T) {
 				AdvertiserID: gofakeit.UUID(),
 				AccountID:    gofakeit.UUID(),
 				ListingID:    gofakeit.Adverb(),
+				Owner:        owner,
 			},
 			expectedError: "listingId: must contain digits only.",
 		},
+		{
+			name: "invalid parent id",
+			comment: comment.Comment{
+				Type:         comment.Lead,
+				Description:  gofakeit.HackerPhrase(),
+				AdvertiserID: gofakeit.UUID(),
+				AccountID:    gofakeit.UUID(),
+				ListingID:    gofakeit.Numerify("##########"),
+				Owner:        owner,
+				ParentID:     &invalidParentID,
+			},
+			expectedError: "parentId: must be no less than 1.",
+		},
 		{
 			name: "invalid owner",
 			comment: comment.Comment{
@@ -118,6 +151,39 @@ func TestValidate(t *testing.T) {
 			},
 			expectedError: "owner: (accountId: cannot be blank; email: cannot be blank; name: cannot be blank.).",
 		},
+		{
+			name: "invalid owner email",
+			comment: comment.Comment{
+				Type:         comment.Lead,
+				Description:  gofakeit.HackerPhrase(),
+				AdvertiserID: gofakeit.UUID(),
+				AccountID:    gofakeit.UUID(),
+				ListingID:    gofakeit.Numerify("##########"),
+				Owner: comment.Owner{
+					Name:      gofakeit.Name(),
+					Email:     gofakeit.Word(),
+					AccountID: gofakeit.UUID(),
+				},
+			},
+			expectedError: "owner: (email: must be a valid email address.).",
+		},
+		{
+			name: "invalid owner role",
+			comment: comment.Comment{
+				Type:         comment.Lead,
+				Description:  gofakeit.HackerPhrase(),
+				AdvertiserID: gofakeit.UUID(),
+				AccountID:    gofakeit.UUID(),
+				ListingID:    gofakeit.Numerify("##########"),
+				Owner: comment.Owner{
+					Name:      gofakeit.Name(),
+					Email:     gofakeit.Email(),
+					AccountID: gofakeit.UUID(),
+					Role:      domain.RoleType(42),
+				},
+			},
+			expectedError: "owner: (role: must be a valid value.).",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -126,3 +192,135 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestTombstone(t *testing.T) {
+	deletedAt := time.Now()
+	parentID := gofakeit.Number(1, 1000)
+	cmt := comment.Comment{
+		ID:           gofakeit.Number(1, 1000),
+		Type:         comment.Lead,
+		Description:  gofakeit.HackerPhrase(),
+		AdvertiserID: gofakeit.UUID(),
+		AccountID:    gofakeit.UUID(),
+		ListingID:    gofakeit.Numerify("##########"),
+		Owner: comment.Owner{
+			Name:      gofakeit.Name(),
+			Email:     gofakeit.Email(),
+			AccountID: gofakeit.UUID(),
+		},
+		DeletedAt:  &deletedAt,
+		DeletedBy:  gofakeit.UUID(),
+		ParentID:   &parentID,
+		Depth:      1,
+		ReplyCount: 2,
+	}
+
+	result := cmt.Tombstone()
+	expected := cmt
+	expected.Description = ""
+	expected.Owner = comment.Owner{}
+	expected.DeletedBy = ""
+	if diff := cmp.Diff(result, expected); diff != "" {
+		t.Errorf("unexpected tombstone %s", diff)
+	}
+}
+
+func TestValidateAttachment(t *testing.T) {
+	testCases := []struct {
+		name          string
+		attachment    comment.Attachment
+		expectedError string
+	}{
+		{
+			name: "valid attachment",
+			attachment: comment.Attachment{
+				FileName:    "contrato.pdf",
+				ContentType: "application/pdf",
+				Size:        1024,
+			},
+		},
+		{
+			name:          "missing fields",
+			attachment:    comment.Attachment{},
+			expectedError: "contentType: cannot be blank; fileName: cannot be blank; size: cannot be blank.",
+		},
+		{
+			name: "unsupported content type",
+			attachment: comment.Attachment{
+				FileName:    "script.sh",
+				ContentType: "text/x-shellscript",
+				Size:        1024,
+			},
+			expectedError: "contentType: must be a valid value.",
+		},
+		{
+			name: "too big file",
+			attachment: comment.Attachment{
+				FileName:    "foto.jpg",
+				ContentType: "image/jpeg",
+				Size:        comment.MaxAttachmentSize + 1,
+			},
+			expectedError: "size: must be no greater than 10485760.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.attachment.Validate()
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestValidateImport(t *testing.T) {
+	cmt := comment.Comment{
+		Type:         comment.Lead,
+		Description:  gofakeit.HackerPhrase(),
+		AdvertiserID: gofakeit.UUID(),
+		AccountID:    gofakeit.UUID(),
+		ListingID:    gofakeit.Numerify("##########"),
+		Owner: comment.Owner{
+			Name:      gofakeit.Name(),
+			Email:     gofakeit.Email(),
+			AccountID: gofakeit.UUID(),
+		},
+	}
+	invalidComment := cmt
+	invalidComment.Description = ""
+
+	testCases := []struct {
+		name          string
+		imp           comment.Import
+		expectedError string
+	}{
+		{
+			name: "valid import",
+			imp: comment.Import{
+				Source:     "crm",
+				ExternalID: gofakeit.UUID(),
+				Comment:    cmt,
+			},
+		},
+		{
+			name: "missing source and external id",
+			imp: comment.Import{
+				Comment: cmt,
+			},
+			expectedError: "externalId: cannot be blank; source: cannot be blank.",
+		},
+		{
+			name: "invalid comment",
+			imp: comment.Import{
+				Source:     "crm",
+				ExternalID: gofakeit.UUID(),
+				Comment:    invalidComment,
+			},
+			expectedError: "comment: (description: cannot be blank.).",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.imp.Validate()
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
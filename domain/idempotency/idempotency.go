@@ -0,0 +1,54 @@
+// Package idempotency holds idempotency keys, which let clients retry requests creating resources without duplicating them
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+// MaxKeyLength of the keys sent by clients
+const MaxKeyLength = 255
+
+// Key sent by a client along a creation request, replays of the same request within the key ttl
+// result in the resource created by the first one and its response
+type Key struct {
+	// Scope of the key, so keys of different clients or operations don't clash
+	Scope string
+	Key   string
+	// RequestHash of the payload of the first request, replays must have the same payload
+	RequestHash string
+	// ResourceID created by the first request
+	ResourceID int
+	// Response of the first request, returned again to its replays
+	Response  Response
+	CreatedAt time.Time
+}
+
+// Response of a request sent along a key, as written to the client
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// ValidateKey sent by a client, it must be printable ascii up to the max key length
+func ValidateKey(key string) error {
+	return validation.Errors{
+		"idempotencyKey": validation.Validate(key, validation.Required, validation.Length(1, MaxKeyLength), is.PrintableASCII),
+	}.Filter()
+}
+
+// Hash a request payload, it's encoded as json so payloads which only differ in formatting have the same hash
+func Hash(payload interface{}) (string, error) {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(bytes)
+	return hex.EncodeToString(hash[:]), nil
+}
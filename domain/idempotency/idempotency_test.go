@@ -0,0 +1,62 @@
+package idempotency_test
+
+import (
+	"go-boilerplate/domain/idempotency"
+	"go-boilerplate/test"
+	"strings"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	testCases := []struct {
+		name          string
+		key           string
+		expectedError string
+	}{
+		{
+			name: "valid key",
+			key:  "6b0d1c0e-6c8e-4f3e-9a43-6c1d2a7a3f10",
+		},
+		{
+			name:          "blank key",
+			key:           "",
+			expectedError: "idempotencyKey: cannot be blank.",
+		},
+		{
+			name:          "key too long",
+			key:           strings.Repeat("a", idempotency.MaxKeyLength+1),
+			expectedError: "idempotencyKey: the length must be between 1 and 255.",
+		},
+		{
+			name:          "key not printable",
+			key:           "key\n",
+			expectedError: "idempotencyKey: must contain printable ASCII characters only.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := idempotency.ValidateKey(tc.key)
+			test.AssertError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	type payload struct {
+		Description string `json:"description"`
+	}
+
+	first, err := idempotency.Hash(payload{Description: "first"})
+	test.AssertError(t, err, "")
+	replay, err := idempotency.Hash(payload{Description: "first"})
+	test.AssertError(t, err, "")
+	other, err := idempotency.Hash(payload{Description: "other"})
+	test.AssertError(t, err, "")
+
+	if first != replay {
+		t.Errorf("unexpected hash %s of the same payload, expected %s", replay, first)
+	}
+	if first == other {
+		t.Errorf("unexpected hash %s of another payload", other)
+	}
+}
@@ -0,0 +1,81 @@
+// Package outbox holds domain events recorded in the same transaction as the changes that raised them,
+// they are relayed to the queue after the transaction commits
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Status of an event in the outbox
+type Status int
+
+const (
+	// StatusNone zero value for this enum
+	StatusNone Status = iota
+	// Pending when the event still has to be published
+	Pending
+	// Published when the event was sent to the queue
+	Published
+	// Dead when the event failed to be published after the maximum attempts
+	Dead
+)
+
+var statusValues = [...]string{
+	"",
+	"PENDING",
+	"PUBLISHED",
+	"DEAD",
+}
+
+func (s Status) String() string {
+	return statusValues[s]
+}
+
+// StatusValueOf converts an outbox status value into an outbox status
+func StatusValueOf(v string) (Status, error) {
+	for i, value := range statusValues {
+		if value == v {
+			return Status(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown outbox status value %s", v)
+}
+
+// Event raised by a change of some aggregate, its json is the message published to the queue
+type Event struct {
+	ID int `json:"id"`
+	// Aggregate name of the changed entity (eg comment)
+	Aggregate   string `json:"aggregate"`
+	AggregateID int    `json:"aggregateId"`
+	// Type of the event (eg CommentCreated)
+	Type string `json:"type"`
+	// Payload is the state of the aggregate after the change
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Status      Status          `json:"-"`
+	Attempts    int             `json:"-"`
+	LastError   string          `json:"-"`
+	AvailableAt time.Time       `json:"-"`
+	PublishedAt *time.Time      `json:"-"`
+}
+
+// Failed records a failed publish attempt, the event is retried after an exponential backoff
+// and is dead once it reaches the maximum attempts
+func (e Event) Failed(err error, maxAttempts int, backoff, maxBackoff time.Duration, now time.Time) Event {
+	e.Attempts++
+	e.LastError = err.Error()
+	if e.Attempts >= maxAttempts {
+		e.Status = Dead
+		return e
+	}
+
+	delay := backoff << (e.Attempts - 1)
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	e.AvailableAt = now.Add(delay)
+
+	return e
+}
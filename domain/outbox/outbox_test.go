@@ -0,0 +1,72 @@
+package outbox_test
+
+import (
+	"errors"
+	"go-boilerplate/domain/outbox"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFailed(t *testing.T) {
+	now := time.Now()
+	err := errors.New("queue unavailable")
+	testCases := []struct {
+		name     string
+		event    outbox.Event
+		expected outbox.Event
+	}{
+		{
+			name:  "first failure",
+			event: outbox.Event{ID: 1, Status: outbox.Pending},
+			expected: outbox.Event{
+				ID:          1,
+				Status:      outbox.Pending,
+				Attempts:    1,
+				LastError:   err.Error(),
+				AvailableAt: now.Add(time.Second),
+			},
+		},
+		{
+			name:  "backoff doubles on each failure",
+			event: outbox.Event{ID: 1, Status: outbox.Pending, Attempts: 3},
+			expected: outbox.Event{
+				ID:          1,
+				Status:      outbox.Pending,
+				Attempts:    4,
+				LastError:   err.Error(),
+				AvailableAt: now.Add(8 * time.Second),
+			},
+		},
+		{
+			name:  "backoff limited",
+			event: outbox.Event{ID: 1, Status: outbox.Pending, Attempts: 8},
+			expected: outbox.Event{
+				ID:          1,
+				Status:      outbox.Pending,
+				Attempts:    9,
+				LastError:   err.Error(),
+				AvailableAt: now.Add(time.Minute),
+			},
+		},
+		{
+			name:  "dead after maximum attempts",
+			event: outbox.Event{ID: 1, Status: outbox.Pending, Attempts: 9},
+			expected: outbox.Event{
+				ID:        1,
+				Status:    outbox.Dead,
+				Attempts:  10,
+				LastError: err.Error(),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.event.Failed(err, 10, time.Second, time.Minute, now)
+			if diff := cmp.Diff(result, tc.expected); diff != "" {
+				t.Errorf("unexpected failed event %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package ratelimit holds token bucket rate limiting, buckets are refilled continuously up to the limit requests
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit of requests allowed per period, bursts of up to the limit requests are allowed
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit in the format requests/period (eg 120/1m)
+func ParseLimit(v string) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(v), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %s, expected requests/period", v)
+	}
+
+	l := Limit{}
+	var err error
+	l.Requests, err = strconv.Atoi(requests)
+	if err != nil || l.Requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit requests %s", requests)
+	}
+	l.Period, err = time.ParseDuration(period)
+	if err != nil || l.Period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit period %s", period)
+	}
+
+	return l, nil
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// rate of tokens refilled per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Bucket of tokens of a client, a zero bucket is full
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result of taking a token from a bucket
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the bucket will be full again
+	Reset time.Duration
+	// RetryAfter is when a token will be available, zero when the request was allowed
+	RetryAfter time.Duration
+}
+
+// Take a token from the given bucket refilled up to now, the request is allowed when there was a token to take
+func (l Limit) Take(b Bucket, now time.Time) (Bucket, Result) {
+	tokens := l.Refill(b, now)
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	return Bucket{Tokens: tokens, UpdatedAt: now}, l.Result(tokens, allowed)
+}
+
+// Refill the tokens of the given bucket up to now
+func (l Limit) Refill(b Bucket, now time.Time) float64 {
+	tokens := float64(l.Requests)
+	if !b.UpdatedAt.IsZero() {
+		elapsed := now.Sub(b.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(tokens, b.Tokens+elapsed*l.rate())
+	}
+	return tokens
+}
+
+// Result of a request given the tokens left in its bucket, after taking one when the request was allowed
+func (l Limit) Result(tokens float64, allowed bool) Result {
+	result := Result{Allowed: allowed, Limit: l.Requests}
+	if !allowed {
+		result.RetryAfter = l.wait(1 - tokens)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = l.wait(float64(l.Requests) - tokens)
+
+	return result
+}
+
+// wait until the given tokens are refilled, rounded up to the second
+func (l Limit) wait(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens/l.rate())) * time.Second
+}
@@ -0,0 +1,103 @@
+package ratelimit_test
+
+import (
+	"go-boilerplate/domain/ratelimit"
+	"go-boilerplate/test"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLimit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expected      ratelimit.Limit
+		expectedError string
+	}{
+		{
+			name:     "valid limit",
+			value:    "120/1m",
+			expected: ratelimit.Limit{Requests: 120, Period: time.Minute},
+		},
+		{
+			name:          "missing period",
+			value:         "120",
+			expectedError: "invalid rate limit 120, expected requests/period",
+		},
+		{
+			name:          "invalid requests",
+			value:         "0/1m",
+			expectedError: "invalid rate limit requests 0",
+		},
+		{
+			name:          "invalid period",
+			value:         "10/minute",
+			expectedError: "invalid rate limit period minute",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ratelimit.ParseLimit(tc.value)
+			test.AssertError(t, err, tc.expectedError)
+
+			if diff := cmp.Diff(result, tc.expected); diff != "" {
+				t.Errorf("unexpected limit %s", diff)
+			}
+		})
+	}
+}
+
+func TestTake(t *testing.T) {
+	now := time.Now()
+	limit := ratelimit.Limit{Requests: 2, Period: 10 * time.Second}
+
+	testCases := []struct {
+		name           string
+		bucket         ratelimit.Bucket
+		at             time.Time
+		expectedBucket ratelimit.Bucket
+		expected       ratelimit.Result
+	}{
+		{
+			name:           "new bucket",
+			at:             now,
+			expectedBucket: ratelimit.Bucket{Tokens: 1, UpdatedAt: now},
+			expected:       ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 5 * time.Second},
+		},
+		{
+			name:           "last token taken",
+			bucket:         ratelimit.Bucket{Tokens: 1, UpdatedAt: now},
+			at:             now,
+			expectedBucket: ratelimit.Bucket{Tokens: 0, UpdatedAt: now},
+			expected:       ratelimit.Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 10 * time.Second},
+		},
+		{
+			name:           "empty bucket",
+			bucket:         ratelimit.Bucket{Tokens: 0, UpdatedAt: now},
+			at:             now.Add(time.Second),
+			expectedBucket: ratelimit.Bucket{Tokens: 0.2, UpdatedAt: now.Add(time.Second)},
+			expected:       ratelimit.Result{Limit: 2, Remaining: 0, Reset: 9 * time.Second, RetryAfter: 4 * time.Second},
+		},
+		{
+			name:           "bucket refilled up to the limit",
+			bucket:         ratelimit.Bucket{Tokens: 0, UpdatedAt: now},
+			at:             now.Add(time.Hour),
+			expectedBucket: ratelimit.Bucket{Tokens: 1, UpdatedAt: now.Add(time.Hour)},
+			expected:       ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 5 * time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bucket, result := limit.Take(tc.bucket, tc.at)
+
+			if diff := cmp.Diff(bucket, tc.expectedBucket); diff != "" {
+				t.Errorf("unexpected bucket %s", diff)
+			}
+			if diff := cmp.Diff(result, tc.expected); diff != "" {
+				t.Errorf("unexpected result %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package advertiser holds advertisers configuration business logic, configs are cached in memory and refreshed periodically
+package advertiser
+
+import (
+	"context"
+	"errors"
+	"go-boilerplate/common"
+	"go-boilerplate/common/lock"
+	"go-boilerplate/domain/advertiser"
+	"go-boilerplate/repository"
+	advertiserRepository "go-boilerplate/repository/advertiser"
+	"sync"
+	"time"
+)
+
+var (
+	instance = &Facade{
+		Configs: advertiserRepository.Get(),
+		Locker:  lock.Get(),
+	}
+)
+
+type Facade struct {
+	Configs advertiserRepository.Repository
+	Locker  lock.Locker
+
+	mu     sync.RWMutex
+	cached *advertiser.Configs
+}
+
+func Get() *Facade {
+	return instance
+}
+
+// FindByID the config of an advertiser, configs are loaded into the cache on first use
+func (f *Facade) FindByID(ctx context.Context, advertiserID string) (advertiser.Config, error) {
+	configs, err := f.cachedConfigs(ctx)
+	if err != nil {
+		return advertiser.Config{}, err
+	}
+
+	cfg, ok := configs.Advertisers[advertiserID]
+	if !ok {
+		return advertiser.Config{}, repository.ErrNotFound
+	}
+
+	return cfg, nil
+}
+
+// FindVersion of the configs of all advertisers kept in the history
+func (f *Facade) FindVersion(ctx context.Context, version int) (advertiser.Configs, error) {
+	return f.Configs.LoadVersion(ctx, version)
+}
+
+// Update the config of an advertiser creating a new version of the configs of all advertisers, returning it.
+// Updates are serialized by a distributed lock so concurrent ones don't overwrite each other
+func (f *Facade) Update(ctx context.Context, cfg advertiser.Config, updatedBy string) (version int, err error) {
+	err = f.Locker.WithLock(ctx, lock.AdvertiserPortalsConfigUpdate, func() error {
+		current, err := f.load(ctx)
+		if err != nil {
+			return err
+		}
+
+		next := current.With(cfg, updatedBy, time.Now())
+		err = next.Validate()
+		if err != nil {
+			return err
+		}
+
+		err = f.Configs.Save(ctx, next)
+		if err != nil {
+			return err
+		}
+		f.store(next)
+		version = next.Version
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Refresh the cache with the current version of the configs, the cache is kept when they're invalid
+func (f *Facade) Refresh(ctx context.Context) error {
+	configs, err := f.load(ctx)
+	if err != nil {
+		return err
+	}
+	f.store(configs)
+
+	return nil
+}
+
+// Watch refreshes the cache periodically until the given context is done
+func (f *Facade) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := f.Refresh(ctx)
+			if err != nil && ctx.Err() == nil {
+				common.HandleError("error refreshing advertiser configs", err)
+			}
+		}
+	}
+}
+
+// load the current version of the configs, they're empty before the first update
+func (f *Facade) load(ctx context.Context) (advertiser.Configs, error) {
+	configs, err := f.Configs.Load(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return advertiser.Configs{}, nil
+	}
+	if err != nil {
+		return advertiser.Configs{}, err
+	}
+
+	err = configs.Validate()
+	if err != nil {
+		return advertiser.Configs{}, err
+	}
+
+	return configs, nil
+}
+
+func (f *Facade) cachedConfigs(ctx context.Context) (advertiser.Configs, error) {
+	f.mu.RLock()
+	cached := f.cached
+	f.mu.RUnlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	configs, err := f.load(ctx)
+	if err != nil {
+		return advertiser.Configs{}, err
+	}
+	f.store(configs)
+
+	return configs, nil
+}
+
+// store the given configs in the cache unless it already holds a newer version
+func (f *Facade) store(configs advertiser.Configs) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cached != nil && f.cached.Version > configs.Version {
+		return
+	}
+	f.cached = &configs
+}
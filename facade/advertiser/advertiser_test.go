@@ -0,0 +1,159 @@
+package advertiser_test
+
+import (
+	"context"
+	"errors"
+	"go-boilerplate/common/lock"
+	"go-boilerplate/domain"
+	"go-boilerplate/domain/advertiser"
+	advertiserFacade "go-boilerplate/facade/advertiser"
+	"go-boilerplate/repository"
+	advertiserRepository "go-boilerplate/repository/advertiser"
+	"go-boilerplate/test"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+)
+
+func anyConfig() advertiser.Config {
+	return advertiser.Config{
+		AdvertiserID: gofakeit.UUID(),
+		Portals: []advertiser.PortalSettings{
+			{Portal: domain.PortalZap, Enabled: true},
+		},
+	}
+}
+
+func TestFindByID(t *testing.T) {
+	cfg := anyConfig()
+	configs := advertiser.Configs{
+		Version:     2,
+		Advertisers: map[string]advertiser.Config{cfg.AdvertiserID: cfg},
+	}
+	configsMock := &advertiserRepository.MockRepository{}
+	configsMock.On("Load", mock.Anything).Return(configs, nil).Once()
+	f := advertiserFacade.Facade{
+		Configs: configsMock,
+	}
+
+	testCases := []struct {
+		name          string
+		advertiserID  string
+		expected      advertiser.Config
+		expectedError error
+	}{
+		{
+			name:         "config loaded into the cache",
+			advertiserID: cfg.AdvertiserID,
+			expected:     cfg,
+		},
+		{
+			name:          "config not found in the cache",
+			advertiserID:  gofakeit.UUID(),
+			expectedError: repository.ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := f.FindByID(context.Background(), tc.advertiserID)
+			test.AssertErrorType(t, err, tc.expectedError)
+
+			if diff := cmp.Diff(result, tc.expected); diff != "" {
+				t.Errorf("unexpected advertiser config %s", diff)
+			}
+		})
+	}
+
+	configsMock.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	cfg := anyConfig()
+	current := anyConfig()
+	configs := advertiser.Configs{
+		Version:     2,
+		Advertisers: map[string]advertiser.Config{current.AdvertiserID: current},
+	}
+	saveErr := errors.New("bucket unavailable")
+	lockErr := errors.New("lock timeout")
+	withLock := func(ctx context.Context, key lock.Key, fn func() error) error {
+		return fn()
+	}
+
+	testCases := []struct {
+		name            string
+		configureMocks  func(configsMock *advertiserRepository.MockRepository, lockerMock *lock.MockLocker)
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name: "config updated under lock",
+			configureMocks: func(configsMock *advertiserRepository.MockRepository, lockerMock *lock.MockLocker) {
+				lockerMock.On("WithLock", mock.Anything, lock.AdvertiserPortalsConfigUpdate, mock.Anything).Return(withLock).Once()
+				configsMock.On("Load", mock.Anything).Return(configs, nil).Once()
+				configsMock.On("Save", mock.Anything, mock.MatchedBy(func(next advertiser.Configs) bool {
+					return next.Version == 3 && next.UpdatedBy == "admin" && len(next.Advertisers) == 2
+				})).Return(nil).Once()
+			},
+			expectedVersion: 3,
+		},
+		{
+			name: "first config created",
+			configureMocks: func(configsMock *advertiserRepository.MockRepository, lockerMock *lock.MockLocker) {
+				lockerMock.On("WithLock", mock.Anything, lock.AdvertiserPortalsConfigUpdate, mock.Anything).Return(withLock).Once()
+				configsMock.On("Load", mock.Anything).Return(advertiser.Configs{}, repository.ErrNotFound).Once()
+				configsMock.On("Save", mock.Anything, mock.MatchedBy(func(next advertiser.Configs) bool {
+					return next.Version == 1 && len(next.Advertisers) == 1
+				})).Return(nil).Once()
+			},
+			expectedVersion: 1,
+		},
+		{
+			name: "saving fails",
+			configureMocks: func(configsMock *advertiserRepository.MockRepository, lockerMock *lock.MockLocker) {
+				lockerMock.On("WithLock", mock.Anything, lock.AdvertiserPortalsConfigUpdate, mock.Anything).Return(withLock).Once()
+				configsMock.On("Load", mock.Anything).Return(configs, nil).Once()
+				configsMock.On("Save", mock.Anything, mock.Anything).Return(saveErr).Once()
+			},
+			expectedError: saveErr,
+		},
+		{
+			name: "lock not acquired",
+			configureMocks: func(configsMock *advertiserRepository.MockRepository, lockerMock *lock.MockLocker) {
+				lockerMock.On("WithLock", mock.Anything, lock.AdvertiserPortalsConfigUpdate, mock.Anything).Return(lockErr).Once()
+			},
+			expectedError: lockErr,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configsMock := &advertiserRepository.MockRepository{}
+			lockerMock := &lock.MockLocker{}
+			tc.configureMocks(configsMock, lockerMock)
+			f := advertiserFacade.Facade{
+				Configs: configsMock,
+				Locker:  lockerMock,
+			}
+
+			version, err := f.Update(context.Background(), cfg, "admin")
+			test.AssertErrorType(t, err, tc.expectedError)
+			if version != tc.expectedVersion {
+				t.Errorf("unexpected configs version %d", version)
+			}
+			if err == nil {
+				result, err := f.FindByID(context.Background(), cfg.AdvertiserID)
+				if err != nil {
+					t.Errorf("unexpected error finding updated config %s", err)
+				}
+				if diff := cmp.Diff(result, cfg); diff != "" {
+					t.Errorf("unexpected cached config %s", diff)
+				}
+			}
+
+			configsMock.AssertExpectations(t)
+			lockerMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/facade"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var attachmentURLExpiration = time.Duration(common.Config.GetInt("commentAttachmentUrlExpirationMinutes")) * time.Minute
+
+// InsertAttachment of a comment returning its id and a presigned URL to upload its content
+func (f *Facade) InsertAttachment(ctx context.Context, att comment.Attachment) (ID int, uploadURL string, err error) {
+	att.Key = fmt.Sprintf("comment/%d/%s", att.CommentID, uuid.NewString())
+	err = facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		var err error
+		ID, err = f.Attachments.Insert(ctx, tx, att)
+		return err
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	uploadURL, err = f.Storage.PresignPut(ctx, att.Key, att.ContentType, att.Size, attachmentURLExpiration)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return ID, uploadURL, nil
+}
+
+// FindAttachmentByID a comment attachment
+func (f *Facade) FindAttachmentByID(ctx context.Context, ID int) (comment.Attachment, error) {
+	return f.Attachments.FindByID(ctx, nil, ID)
+}
+
+// FindAttachments and count attachments of a given comment
+func (f *Facade) FindAttachments(ctx context.Context, commentID int, p pagination.Pagination) ([]comment.Attachment, int, error) {
+	results, err := f.Attachments.Find(ctx, nil, commentID, p)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count, err := f.Attachments.Count(ctx, nil, commentID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, count, nil
+}
+
+// AttachmentDownloadURL returns a presigned URL to download the content of an attachment
+func (f *Facade) AttachmentDownloadURL(ctx context.Context, att comment.Attachment) (string, error) {
+	return f.Storage.PresignGet(ctx, att.Key, att.FileName, attachmentURLExpiration)
+}
@@ -0,0 +1,118 @@
+package comment_test
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/domain/comment"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+)
+
+func anyAttachment() comment.Attachment {
+	return comment.Attachment{
+		ID:          gofakeit.Number(1, 1000),
+		CommentID:   gofakeit.Number(1, 1000),
+		FileName:    "contrato.pdf",
+		ContentType: "application/pdf",
+		Size:        1024,
+		CreatedBy:   gofakeit.UUID(),
+	}
+}
+
+func TestInsertAttachment(t *testing.T) {
+	att := anyAttachment()
+	uploadURL := gofakeit.URL()
+	withKey := mock.MatchedBy(func(a comment.Attachment) bool {
+		return a.FileName == att.FileName && strings.HasPrefix(a.Key, "comment/")
+	})
+
+	testCases := []struct {
+		name           string
+		attachment     comment.Attachment
+		configureMocks func()
+	}{
+		{
+			name:       "attachment inserted successfully",
+			attachment: att,
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				attachmentsMock.On("Insert", mock.Anything, mock.Anything, withKey).Return(att.ID, nil).Once()
+				storageMock.On("PresignPut", mock.Anything, mock.AnythingOfType("string"), att.ContentType, att.Size, 15*time.Minute).Return(uploadURL, nil).Once()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			ID, result, err := f.InsertAttachment(context.Background(), tc.attachment)
+			if err != nil {
+				t.Errorf("error inserting attachment %s", err)
+				return
+			}
+			if ID != att.ID || result != uploadURL {
+				t.Errorf("unexpected attachment upload %d %s", ID, result)
+				return
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
+func TestFindAttachments(t *testing.T) {
+	att := anyAttachment()
+	p, _ := pagination.New(0, 10)
+
+	testCases := []struct {
+		name           string
+		commentID      int
+		configureMocks func()
+		expected       []comment.Attachment
+		expectedCount  int
+	}{
+		{
+			name:      "some attachments found",
+			commentID: att.CommentID,
+			configureMocks: func() {
+				attachmentsMock.On("Find", mock.Anything, (*sql.Tx)(nil), att.CommentID, p).Return([]comment.Attachment{
+					att,
+				}, nil).Once()
+				attachmentsMock.On("Count", mock.Anything, (*sql.Tx)(nil), att.CommentID).Return(1, nil).Once()
+			},
+			expected: []comment.Attachment{
+				att,
+			},
+			expectedCount: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			results, count, err := f.FindAttachments(context.Background(), tc.commentID, p)
+			if err != nil {
+				t.Errorf("error finding attachments %s", err)
+				return
+			}
+			if diff := cmp.Diff(results, tc.expected); diff != "" {
+				t.Errorf("unexpected attachments %s", diff)
+				return
+			}
+			if count != tc.expectedCount {
+				t.Errorf("unexpected attachments count %d", count)
+				return
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/facade"
+	"go-boilerplate/repository"
+)
+
+// Bulk executes the given operations changed by the given account, operations on comments of advertisers and accounts
+// the given access check denies fail with repository.ErrUnauthorizedResource. Operations are executed in request order,
+// consecutive creates are inserted together by multi-row inserts.
+// In all-or-nothing mode every operation is executed in a single transaction, rolled back by the first failed one whose error is returned,
+// while in partial mode failed operations don't affect the others and their errors are returned in their results
+func (f *Facade) Bulk(ctx context.Context, mode comment.BulkMode, ops []comment.BulkOperation, changedBy string,
+	canAccess func(advertiserID, accountID string) bool) ([]comment.BulkResult, error) {
+	results := make([]comment.BulkResult, len(ops))
+	if mode == comment.BulkPartial {
+		f.bulkPartial(ctx, ops, results, changedBy, canAccess)
+		return results, nil
+	}
+
+	published := &publishedEvents{}
+	err := facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		for start, end := 0, 0; start < len(ops); start = end {
+			end = bulkRunEnd(ops, start)
+			if ops[start].Action == comment.BulkCreate {
+				err := f.bulkCreate(ctx, tx, published, ops[start:end], results[start:end], canAccess)
+				if err != nil {
+					return err
+				}
+				for i := start; i < end; i++ {
+					if results[i].Err != nil {
+						return fmt.Errorf("operation %d: %w", i, results[i].Err)
+					}
+				}
+				continue
+			}
+
+			err := f.bulkChange(ctx, tx, published, ops[start], changedBy, canAccess)
+			if err != nil {
+				return fmt.Errorf("operation %d: %w", start, err)
+			}
+			results[start].ID = ops[start].ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	published.count()
+
+	return results, nil
+}
+
+// bulkRunEnd returns the end of the run of operations starting at the given one, consecutive creates run together
+// to be inserted by multi-row inserts while each update and delete runs alone
+func bulkRunEnd(ops []comment.BulkOperation, start int) int {
+	end := start + 1
+	if ops[start].Action != comment.BulkCreate {
+		return end
+	}
+	for end < len(ops) && ops[end].Action == comment.BulkCreate {
+		end++
+	}
+	return end
+}
+
+// bulkPartial executes each run of consecutive creates in a single transaction and each update and delete in its own one,
+// in request order, recording their results
+func (f *Facade) bulkPartial(ctx context.Context, ops []comment.BulkOperation, results []comment.BulkResult, changedBy string,
+	canAccess func(advertiserID, accountID string) bool) {
+	for start, end := 0, 0; start < len(ops); start = end {
+		end = bulkRunEnd(ops, start)
+		if ops[start].Action == comment.BulkCreate {
+			f.bulkPartialCreate(ctx, ops[start:end], results[start:end], canAccess)
+			continue
+		}
+
+		published := &publishedEvents{}
+		err := facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+			return f.bulkChange(ctx, tx, published, ops[start], changedBy, canAccess)
+		})
+		if err != nil {
+			results[start].Err = err
+			continue
+		}
+		published.count()
+		results[start].ID = ops[start].ID
+	}
+}
+
+// bulkPartialCreate inserts the given creates in a single transaction, retrying them one by one when it fails
+// so only the failed ones fail
+func (f *Facade) bulkPartialCreate(ctx context.Context, ops []comment.BulkOperation, results []comment.BulkResult,
+	canAccess func(advertiserID, accountID string) bool) {
+	published := &publishedEvents{}
+	err := facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		return f.bulkCreate(ctx, tx, published, ops, results, canAccess)
+	})
+	if err == nil {
+		published.count()
+		return
+	}
+
+	// the events of the failed transaction weren't counted, the ones of the retried creates are counted by Insert
+	for i, op := range ops {
+		if !canAccess(op.Comment.AdvertiserID, op.Comment.AccountID) {
+			results[i] = comment.BulkResult{Err: repository.ErrUnauthorizedResource}
+			continue
+		}
+		ID, err := f.Insert(ctx, *op.Comment)
+		results[i] = comment.BulkResult{ID: ID, Err: err}
+	}
+}
+
+// bulkCreate inserts the comments of the create operations by multi-row inserts, publishing their creation.
+// Operations which can't be inserted have their errors recorded in their results, leaving them out of the inserts
+func (f *Facade) bulkCreate(ctx context.Context, tx *sql.Tx, published *publishedEvents, ops []comment.BulkOperation, results []comment.BulkResult,
+	canAccess func(advertiserID, accountID string) bool) error {
+	cmts := []comment.Comment{}
+	indexes := []int{}
+	for i, op := range ops {
+		if op.Action != comment.BulkCreate {
+			continue
+		}
+		if !canAccess(op.Comment.AdvertiserID, op.Comment.AccountID) {
+			results[i].Err = repository.ErrUnauthorizedResource
+			continue
+		}
+
+		cmt, err := f.prepare(ctx, tx, *op.Comment)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		cmts = append(cmts, cmt)
+		indexes = append(indexes, i)
+	}
+	if len(cmts) == 0 {
+		return nil
+	}
+
+	IDs, err := f.Comments.InsertBatch(ctx, tx, cmts)
+	if err != nil {
+		return err
+	}
+	for j, ID := range IDs {
+		results[indexes[j]].ID = ID
+		err := f.publishEvent(ctx, tx, published, ID, comment.Created)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bulkChange executes an update or delete operation, recording the previous state of its comment as a revision
+// changed by the given account and publishing the change
+func (f *Facade) bulkChange(ctx context.Context, tx *sql.Tx, published *publishedEvents, op comment.BulkOperation, changedBy string,
+	canAccess func(advertiserID, accountID string) bool) error {
+	current, err := f.Comments.FindByID(ctx, tx, op.ID, false)
+	if err != nil {
+		return err
+	}
+	if !canAccess(current.AdvertiserID, current.AccountID) {
+		return repository.ErrUnauthorizedResource
+	}
+
+	switch op.Action {
+	case comment.BulkUpdate:
+		if !canAccess(op.Comment.AdvertiserID, op.Comment.AccountID) {
+			return repository.ErrUnauthorizedResource
+		}
+		_, err = f.Revisions.Insert(ctx, tx, comment.NewRevision(current, comment.RevisionUpdate, changedBy))
+		if err != nil {
+			return err
+		}
+
+		cmt := *op.Comment
+		cmt.ID = op.ID
+		cmt.Version = op.Version
+		err = f.Comments.Update(ctx, tx, cmt)
+		if err != nil {
+			return err
+		}
+
+		return f.publishEvent(ctx, tx, published, op.ID, comment.Updated)
+	case comment.BulkDelete:
+		_, err = f.Revisions.Insert(ctx, tx, comment.NewRevision(current, comment.RevisionDelete, changedBy))
+		if err != nil {
+			return err
+		}
+
+		err = f.Comments.Delete(ctx, tx, op.ID, op.Version, changedBy)
+		if err != nil {
+			return err
+		}
+
+		return f.publishEvent(ctx, tx, published, op.ID, comment.Deleted)
+	}
+
+	return fmt.Errorf("unknown bulk action %s", op.Action)
+}
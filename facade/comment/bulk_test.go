@@ -0,0 +1,140 @@
+package comment_test
+
+import (
+	"context"
+	"errors"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/repository"
+	"go-boilerplate/test"
+	"go-boilerplate/test/fixtures"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBulk(t *testing.T) {
+	changedBy := gofakeit.UUID()
+	created := fixtures.AnyComment()
+	created.ID = 0
+	other := fixtures.AnyComment()
+	other.ID = 0
+	current := fixtures.AnyComment()
+	current.AdvertiserID = created.AdvertiserID
+	current.AccountID = created.AccountID
+	updated := current
+	updated.Description = gofakeit.HackerPhrase()
+	canAccess := func(advertiserID, accountID string) bool {
+		return advertiserID == created.AdvertiserID && accountID == created.AccountID
+	}
+	batchErr := errors.New("batch failed")
+
+	createOp := comment.BulkOperation{Action: comment.BulkCreate, Comment: &created}
+	otherCreateOp := comment.BulkOperation{Action: comment.BulkCreate, Comment: &other}
+	updateOp := comment.BulkOperation{Action: comment.BulkUpdate, ID: current.ID, Version: 1, Comment: &updated}
+	deleteOp := comment.BulkOperation{Action: comment.BulkDelete, ID: current.ID}
+	expectedUpdate := updated
+	expectedUpdate.Version = 1
+
+	testCases := []struct {
+		name           string
+		mode           comment.BulkMode
+		operations     []comment.BulkOperation
+		configureMocks func()
+		expected       []comment.BulkResult
+		expectedErr    error
+	}{
+		{
+			name:       "all operations executed",
+			mode:       comment.BulkAllOrNothing,
+			operations: []comment.BulkOperation{updateOp, createOp},
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("InsertBatch", mock.Anything, mock.Anything, []comment.Comment{created}).Return([]int{100}, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, 100, true).Return(created, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(created, comment.Created)).Return(nil).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, current.ID, false).Return(current, nil).Once()
+				revisionsMock.On("Insert", mock.Anything, mock.Anything, comment.NewRevision(current, comment.RevisionUpdate, changedBy)).Return(1, nil).Once()
+				commentsMock.On("Update", mock.Anything, mock.Anything, expectedUpdate).Return(nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, current.ID, true).Return(updated, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(updated, comment.Updated)).Return(nil).Once()
+			},
+			expected: []comment.BulkResult{{ID: current.ID}, {ID: 100}},
+		},
+		{
+			name:       "first failed operation fails all",
+			mode:       comment.BulkAllOrNothing,
+			operations: []comment.BulkOperation{otherCreateOp, deleteOp},
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+			},
+			expectedErr: repository.ErrUnauthorizedResource,
+		},
+		{
+			name:       "operations executed in request order",
+			mode:       comment.BulkAllOrNothing,
+			operations: []comment.BulkOperation{deleteOp, createOp},
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, current.ID, false).Return(comment.Comment{}, repository.ErrNotFound).Once()
+			},
+			expectedErr: repository.ErrNotFound,
+		},
+		{
+			name:       "failed operations don't affect the others",
+			mode:       comment.BulkPartial,
+			operations: []comment.BulkOperation{otherCreateOp, createOp, deleteOp},
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Twice()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Twice()
+
+				commentsMock.On("InsertBatch", mock.Anything, mock.Anything, []comment.Comment{created}).Return([]int{100}, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, 100, true).Return(created, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(created, comment.Created)).Return(nil).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, current.ID, false).Return(comment.Comment{}, repository.ErrNotFound).Once()
+			},
+			expected: []comment.BulkResult{
+				{Err: repository.ErrUnauthorizedResource},
+				{ID: 100},
+				{Err: repository.ErrNotFound},
+			},
+		},
+		{
+			name:       "creates retried one by one when their batch fails",
+			mode:       comment.BulkPartial,
+			operations: []comment.BulkOperation{createOp},
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Twice()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Twice()
+
+				commentsMock.On("InsertBatch", mock.Anything, mock.Anything, []comment.Comment{created}).Return(nil, batchErr).Once()
+				commentsMock.On("Insert", mock.Anything, mock.Anything, created).Return(100, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, 100, true).Return(created, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(created, comment.Created)).Return(nil).Once()
+			},
+			expected: []comment.BulkResult{{ID: 100}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			results, err := f.Bulk(context.Background(), tc.mode, tc.operations, changedBy, canAccess)
+			test.AssertErrorType(t, err, tc.expectedErr)
+			if diff := cmp.Diff(results, tc.expected, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("unexpected results %s", diff)
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
@@ -2,64 +2,221 @@
 package comment
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/common/metrics"
 	"go-boilerplate/common/pagination"
 	"go-boilerplate/domain/comment"
+	"go-boilerplate/domain/idempotency"
 	"go-boilerplate/facade"
+	"go-boilerplate/repository"
 	commentRepository "go-boilerplate/repository/comment"
+	attachmentRepository "go-boilerplate/repository/comment/attachment"
+	externalRepository "go-boilerplate/repository/comment/external"
+	revisionRepository "go-boilerplate/repository/comment/revision"
+	idempotencyRepository "go-boilerplate/repository/idempotency"
+	"go-boilerplate/repository/storage"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
+	// ErrParentNotFound when a reply targets a missing or soft deleted comment
+	ErrParentNotFound = fmt.Errorf("%w: parent comment not found", repository.ErrUnprocessableEntityResource)
+	// ErrParentMismatch when a reply targets a comment of another advertiser or listing
+	ErrParentMismatch = fmt.Errorf("%w: parent comment belongs to another advertiser or listing", repository.ErrUnprocessableEntityResource)
+	// ErrMaxDepth when a reply would be nested deeper than allowed
+	ErrMaxDepth = fmt.Errorf("%w: replies can't be nested deeper than %d levels", repository.ErrUnprocessableEntityResource, comment.MaxDepth)
+	// ErrIdempotencyKeyReused when an idempotency key is sent again along another comment
+	ErrIdempotencyKeyReused = fmt.Errorf("%w: idempotency key already used by another request", repository.ErrUnprocessableEntityResource)
+
+	idempotencyKeyTTL = time.Duration(common.Config.GetInt64("idempotencyKeyTtlHours")) * time.Hour
+	// idempotencyScopePrefix of the scopes of the keys sent along comment creations
+	idempotencyScopePrefix = "comment:"
+
+	commentEvents = metrics.Factory().NewCounterVec(prometheus.CounterOpts{
+		Name: "comment_events_total",
+		Help: "Comments created, updated and deleted by event and comment type",
+	}, []string{"event", "type"})
+
 	instance = &Facade{
-		TxManager: facade.GetTxManager(),
-		Comments:  commentRepository.Get(),
+		TxManager:   facade.GetTxManager(),
+		Comments:    commentRepository.Get(),
+		Revisions:   revisionRepository.Get(),
+		Attachments: attachmentRepository.Get(),
+		Imports:     externalRepository.Get(),
+		Keys:        idempotencyRepository.Get(),
+		Storage:     storage.Get(),
 	}
 )
 
 type Facade struct {
-	TxManager facade.TxManager
-	Comments  commentRepository.Repository
+	TxManager   facade.TxManager
+	Comments    commentRepository.Repository
+	Revisions   revisionRepository.Repository
+	Attachments attachmentRepository.Repository
+	Imports     externalRepository.Repository
+	Keys        idempotencyRepository.Repository
+	Storage     storage.Storage
 }
 
 func Get() *Facade {
 	return instance
 }
 
-// Insert a comment
-func (f *Facade) Insert(cmt comment.Comment) (ID int, err error) {
-	err = facade.WithTxManager(f.TxManager, func(tx *sql.Tx) error {
+// Insert a comment publishing its creation, replies must target an active comment of the same advertiser and listing
+func (f *Facade) Insert(ctx context.Context, cmt comment.Comment) (ID int, err error) {
+	published := &publishedEvents{}
+	err = facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
 		var err error
-		ID, err = f.Comments.Insert(tx, cmt)
+		ID, err = f.insert(ctx, tx, published, cmt)
+		return err
+	})
+	if err == nil {
+		published.count()
+	}
+
+	return
+}
+
+// InsertIdempotent inserts a comment like Insert, recording the given idempotency key of the scope in the same transaction
+// along the response built by respond for the inserted comment. Replays of the key along the same comment within its ttl
+// result in the response to the first request and replayed set, while sending the key along another comment results in ErrIdempotencyKeyReused
+func (f *Facade) InsertIdempotent(ctx context.Context, cmt comment.Comment, scope, key string,
+	respond func(ID int) (idempotency.Response, error)) (resp idempotency.Response, replayed bool, err error) {
+	hash, err := idempotency.Hash(cmt)
+	if err != nil {
+		return idempotency.Response{}, false, err
+	}
+
+	published := &publishedEvents{}
+	err = facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		k := idempotency.Key{Scope: scope, Key: key, RequestHash: hash}
+		reserved, err := f.Keys.Reserve(ctx, tx, k, time.Now().Add(-idempotencyKeyTTL))
+		if err != nil {
+			return err
+		}
+		if !reserved {
+			first, err := f.Keys.FindByKey(ctx, tx, scope, key)
+			if err != nil {
+				return err
+			}
+			if first.RequestHash != hash {
+				return ErrIdempotencyKeyReused
+			}
+			resp, replayed = first.Response, true
+			return nil
+		}
+
+		ID, err := f.insert(ctx, tx, published, cmt)
+		if err != nil {
+			return err
+		}
+		resp, err = respond(ID)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return f.Keys.Complete(ctx, tx, scope, key, ID, resp)
 	})
+	if err != nil {
+		return idempotency.Response{}, false, err
+	}
+	published.count()
+
+	return resp, replayed, nil
+}
+
+// IdempotencyScope of the keys sent by the given client along comment creations, so keys of different clients don't clash
+func IdempotencyScope(advertiserID, accountID string) string {
+	return idempotencyScopePrefix + advertiserID + ":" + accountID
+}
+
+// Import a comment done in another CRM system, a comment already imported with the same source and external ID
+// isn't imported again and its ID is returned instead
+func (f *Facade) Import(ctx context.Context, imp comment.Import) (ID int, err error) {
+	published := &publishedEvents{}
+	err = facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		var err error
+		ID, err = f.Imports.FindCommentID(ctx, tx, imp.Source, imp.ExternalID)
+		// either already imported or failed to be found
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		ID, err = f.insert(ctx, tx, published, imp.Comment)
+		if err != nil {
+			return err
+		}
+
+		return f.Imports.Insert(ctx, tx, imp.Source, imp.ExternalID, ID)
+	})
+	if err == nil {
+		published.count()
+	}
 
 	return
 }
 
-// Update a comment
-func (f *Facade) Update(cmt comment.Comment) error {
-	return facade.WithTxManager(f.TxManager, func(tx *sql.Tx) error {
-		return f.Comments.Update(tx, cmt)
+// Update a comment recording its previous state as a revision changed by the given account and publishing the change,
+// returning the updated comment with its new version. The comment version is checked when it is set
+func (f *Facade) Update(ctx context.Context, cmt comment.Comment, changedBy string) (comment.Comment, error) {
+	published := &publishedEvents{}
+	updated := comment.Comment{}
+	err := facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		err := f.insertRevision(ctx, tx, cmt.ID, false, comment.RevisionUpdate, changedBy)
+		if err != nil {
+			return err
+		}
+
+		err = f.Comments.Update(ctx, tx, cmt)
+		if err != nil {
+			return err
+		}
+
+		updated, err = f.Comments.FindByID(ctx, tx, cmt.ID, true)
+		if err != nil {
+			return err
+		}
+
+		return f.publish(ctx, tx, published, updated, comment.Updated)
 	})
+	if err != nil {
+		return comment.Comment{}, err
+	}
+	published.count()
+
+	return updated, nil
 }
 
-// FindByID a comment
-func (f *Facade) FindByID(ID int) (comment.Comment, error) {
-	return f.Comments.FindByID(nil, ID)
+// FindByID a comment, soft deleted ones are only found when includeDeleted is set
+func (f *Facade) FindByID(ctx context.Context, ID int, includeDeleted bool) (comment.Comment, error) {
+	return f.Comments.FindByID(ctx, nil, ID, includeDeleted)
 }
 
-// Find and count comments given a query
-func (f *Facade) Find(q commentRepository.Query, p pagination.Pagination) ([]comment.Comment, int, error) {
-	results, err := f.Comments.Find(nil, q, p)
+// Find and count comments given a query, the count is zero when the pagination skips it.
+// Soft deleted comments kept in threads because of their replies are returned as tombstones unless deleted ones are included
+func (f *Facade) Find(ctx context.Context, q commentRepository.Query, p pagination.Pagination) ([]comment.Comment, int, error) {
+	results, err := f.Comments.Find(ctx, nil, q, p)
 	if err != nil {
 		return nil, 0, err
 	}
+	if !q.IncludeDeleted {
+		for i, result := range results {
+			if result.Deleted() {
+				results[i] = result.Tombstone()
+			}
+		}
+	}
+	if p.SkipCount() {
+		return results, 0, nil
+	}
 
-	count, err := f.Comments.Count(nil, q)
+	count, err := f.Comments.Count(ctx, nil, q)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -67,9 +224,200 @@ func (f *Facade) Find(q commentRepository.Query, p pagination.Pagination) ([]com
 	return results, count, err
 }
 
-// Delete a comment
-func (f *Facade) Delete(ID int) (err error) {
-	return facade.WithTxManager(f.TxManager, func(tx *sql.Tx) error {
-		return f.Comments.Delete(tx, ID)
+// Search comments with full-text search terms and count them, the count is zero when the pagination skips it
+func (f *Facade) Search(ctx context.Context, q commentRepository.Query, p pagination.Pagination) ([]comment.SearchResult, int, error) {
+	results, err := f.Comments.Search(ctx, nil, q, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.SkipCount() {
+		return results, 0, nil
+	}
+
+	count, err := f.Comments.Count(ctx, nil, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, count, err
+}
+
+// Delete soft deletes a comment recording its last state as a revision changed by the given account and publishing the deletion,
+// the comment version is checked when it is set
+func (f *Facade) Delete(ctx context.Context, ID, version int, changedBy string) error {
+	published := &publishedEvents{}
+	err := facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		err := f.insertRevision(ctx, tx, ID, false, comment.RevisionDelete, changedBy)
+		if err != nil {
+			return err
+		}
+
+		err = f.Comments.Delete(ctx, tx, ID, version, changedBy)
+		if err != nil {
+			return err
+		}
+
+		return f.publishEvent(ctx, tx, published, ID, comment.Deleted)
 	})
+	if err != nil {
+		return err
+	}
+	published.count()
+
+	return nil
+}
+
+// Restore a soft deleted comment recording it as a revision changed by the given account
+func (f *Facade) Restore(ctx context.Context, ID int, changedBy string) error {
+	return facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		err := f.insertRevision(ctx, tx, ID, true, comment.RevisionRestore, changedBy)
+		if err != nil {
+			return err
+		}
+
+		return f.Comments.Restore(ctx, tx, ID)
+	})
+}
+
+// Purge permanently deletes comments soft deleted before the given time with their revisions, attachments,
+// import links and the idempotency keys which created them
+func (f *Facade) Purge(ctx context.Context, before time.Time) (count int, err error) {
+	err = facade.WithTxManager(ctx, f.TxManager, func(tx *sql.Tx) error {
+		IDs, err := f.Comments.Purge(ctx, tx, before)
+		if err != nil {
+			return err
+		}
+		count = len(IDs)
+		if count == 0 {
+			return nil
+		}
+
+		err = f.Revisions.DeleteByComments(ctx, tx, IDs)
+		if err != nil {
+			return err
+		}
+
+		err = f.Imports.DeleteByComments(ctx, tx, IDs)
+		if err != nil {
+			return err
+		}
+
+		err = f.Keys.DeleteByResources(ctx, tx, idempotencyScopePrefix, IDs)
+		if err != nil {
+			return err
+		}
+
+		keys, err := f.Attachments.DeleteByComments(ctx, tx, IDs)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+
+		return f.Storage.Delete(ctx, keys)
+	})
+
+	return
+}
+
+// FindRevisions and count revisions of a given comment, the count is zero when the pagination skips it
+func (f *Facade) FindRevisions(ctx context.Context, commentID int, p pagination.Pagination) ([]comment.Revision, int, error) {
+	results, err := f.Revisions.Find(ctx, nil, commentID, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.SkipCount() {
+		return results, 0, nil
+	}
+
+	count, err := f.Revisions.Count(ctx, nil, commentID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return results, count, nil
+}
+
+func (f *Facade) insertRevision(ctx context.Context, tx *sql.Tx, ID int, includeDeleted bool, action comment.RevisionAction, changedBy string) error {
+	current, err := f.Comments.FindByID(ctx, tx, ID, includeDeleted)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Revisions.Insert(ctx, tx, comment.NewRevision(current, action, changedBy))
+	return err
+}
+
+func (f *Facade) insert(ctx context.Context, tx *sql.Tx, published *publishedEvents, cmt comment.Comment) (int, error) {
+	cmt, err := f.prepare(ctx, tx, cmt)
+	if err != nil {
+		return 0, err
+	}
+
+	ID, err := f.Comments.Insert(ctx, tx, cmt)
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, f.publishEvent(ctx, tx, published, ID, comment.Created)
+}
+
+// prepare a comment to be inserted, replies must target an active comment of the same advertiser and listing
+// and are nested one level below it
+func (f *Facade) prepare(ctx context.Context, tx *sql.Tx, cmt comment.Comment) (comment.Comment, error) {
+	if cmt.ParentID == nil {
+		return cmt, nil
+	}
+
+	parent, err := f.Comments.FindByID(ctx, tx, *cmt.ParentID, false)
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment.Comment{}, ErrParentNotFound
+	}
+	if err != nil {
+		return comment.Comment{}, err
+	}
+	if parent.AdvertiserID != cmt.AdvertiserID || parent.ListingID != cmt.ListingID {
+		return comment.Comment{}, ErrParentMismatch
+	}
+	if parent.Depth >= comment.MaxDepth {
+		return comment.Comment{}, ErrMaxDepth
+	}
+	cmt.Depth = parent.Depth + 1
+
+	return cmt, nil
+}
+
+// publishedEvents within a transaction, counted by event and comment type only once it commits
+type publishedEvents struct {
+	labels [][]string
+}
+
+func (p *publishedEvents) count() {
+	for _, labels := range p.labels {
+		commentEvents.WithLabelValues(labels...).Inc()
+	}
+}
+
+// publishEvent of the current state of the given comment within the transaction that changed it, collecting it to be counted
+func (f *Facade) publishEvent(ctx context.Context, tx *sql.Tx, published *publishedEvents, ID int, eventType comment.EventType) error {
+	current, err := f.Comments.FindByID(ctx, tx, ID, true)
+	if err != nil {
+		return err
+	}
+
+	return f.publish(ctx, tx, published, current, eventType)
+}
+
+// publish an event of the given type with the given current state of its comment
+func (f *Facade) publish(ctx context.Context, tx *sql.Tx, published *publishedEvents, current comment.Comment, eventType comment.EventType) error {
+	event, err := comment.NewEvent(current, eventType)
+	if err != nil {
+		return err
+	}
+
+	err = f.TxManager.Publish(ctx, tx, event)
+	if err != nil {
+		return err
+	}
+	published.labels = append(published.labels, []string{eventType.String(), current.Type.String()})
+
+	return nil
 }
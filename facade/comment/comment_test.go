@@ -1,14 +1,28 @@
 package comment_test
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"go-boilerplate/common/pagination"
 	"go-boilerplate/domain/comment"
+	"go-boilerplate/domain/idempotency"
+	"go-boilerplate/domain/outbox"
 	"go-boilerplate/facade"
 	commentFacade "go-boilerplate/facade/comment"
+	"go-boilerplate/repository"
 	commentRepository "go-boilerplate/repository/comment"
+	attachmentRepository "go-boilerplate/repository/comment/attachment"
+	externalRepository "go-boilerplate/repository/comment/external"
+	revisionRepository "go-boilerplate/repository/comment/revision"
+	idempotencyRepository "go-boilerplate/repository/idempotency"
+	"go-boilerplate/repository/storage"
+	"go-boilerplate/test"
 	"go-boilerplate/test/fixtures"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v5"
 	"github.com/google/go-cmp/cmp"
@@ -17,43 +31,267 @@ import (
 )
 
 var (
-	txManagerMock = &facade.MockTxManager{}
-	commentsMock  = &commentRepository.MockRepository{}
-	f             = commentFacade.Facade{
-		TxManager: txManagerMock,
-		Comments:  commentsMock,
+	txManagerMock   = &facade.MockTxManager{}
+	commentsMock    = &commentRepository.MockRepository{}
+	revisionsMock   = &revisionRepository.MockRepository{}
+	attachmentsMock = &attachmentRepository.MockRepository{}
+	importsMock     = &externalRepository.MockRepository{}
+	keysMock        = &idempotencyRepository.MockRepository{}
+	storageMock     = &storage.MockStorage{}
+	f               = commentFacade.Facade{
+		TxManager:   txManagerMock,
+		Comments:    commentsMock,
+		Revisions:   revisionsMock,
+		Attachments: attachmentsMock,
+		Imports:     importsMock,
+		Keys:        keysMock,
+		Storage:     storageMock,
 	}
 	verifyAllMocks = func(t *testing.T) {
 		txManagerMock.AssertExpectations(t)
 		commentsMock.AssertExpectations(t)
+		revisionsMock.AssertExpectations(t)
+		attachmentsMock.AssertExpectations(t)
+		importsMock.AssertExpectations(t)
+		keysMock.AssertExpectations(t)
+		storageMock.AssertExpectations(t)
 	}
 )
 
+// anyEvent of the given comment change
+func anyEvent(cmt comment.Comment, eventType comment.EventType) outbox.Event {
+	e, _ := comment.NewEvent(cmt, eventType)
+	return e
+}
+
 func TestInsert(t *testing.T) {
 	cmt := fixtures.AnyComment()
+
+	parent := fixtures.AnyComment()
+	parent.ID = gofakeit.Number(1, 1000)
+	parent.Depth = 1
+	reply := fixtures.AnyComment()
+	reply.AdvertiserID = parent.AdvertiserID
+	reply.ListingID = parent.ListingID
+	reply.ParentID = &parent.ID
+	expectedReply := reply
+	expectedReply.Depth = 2
+
+	deepParent := parent
+	deepParent.Depth = comment.MaxDepth
+	otherListingParent := parent
+	otherListingParent.ListingID = gofakeit.Numerify("##########")
+
 	testCases := []struct {
 		name           string
 		comment        comment.Comment
 		configureMocks func()
+		expectedErr    error
 	}{
 		{
 			name:    "comment inserted successfully",
 			comment: cmt,
 			configureMocks: func() {
-				txManagerMock.On("Begin").Return(nil, nil, nil).Once()
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("Insert", mock.Anything, mock.Anything, cmt).Return(cmt.ID, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, cmt.ID, true).Return(cmt, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(cmt, comment.Created)).Return(nil).Once()
+			},
+		},
+		{
+			name:    "reply inserted successfully",
+			comment: reply,
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, parent.ID, false).Return(parent, nil).Once()
+				commentsMock.On("Insert", mock.Anything, mock.Anything, expectedReply).Return(reply.ID, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, reply.ID, true).Return(expectedReply, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(expectedReply, comment.Created)).Return(nil).Once()
+			},
+		},
+		{
+			name:    "reply to a missing parent",
+			comment: reply,
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, parent.ID, false).Return(comment.Comment{}, repository.ErrNotFound).Once()
+			},
+			expectedErr: commentFacade.ErrParentNotFound,
+		},
+		{
+			name:    "reply to a parent of another listing",
+			comment: reply,
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, parent.ID, false).Return(otherListingParent, nil).Once()
+			},
+			expectedErr: commentFacade.ErrParentMismatch,
+		},
+		{
+			name:    "reply nested too deep",
+			comment: reply,
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, parent.ID, false).Return(deepParent, nil).Once()
+			},
+			expectedErr: commentFacade.ErrMaxDepth,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			_, err := f.Insert(context.Background(), tc.comment)
+			test.AssertErrorType(t, err, tc.expectedErr)
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
+func TestInsertIdempotent(t *testing.T) {
+	cmt := fixtures.AnyComment()
+	hash, _ := idempotency.Hash(cmt)
+	scope := commentFacade.IdempotencyScope(cmt.AdvertiserID, cmt.AccountID)
+	key := gofakeit.UUID()
+	reserved := idempotency.Key{Scope: scope, Key: key, RequestHash: hash}
+	resp := idempotency.Response{Status: 201, Body: []byte(fmt.Sprintf(`{"id":%d}`, cmt.ID))}
+	respond := func(ID int) (idempotency.Response, error) {
+		return idempotency.Response{Status: 201, Body: []byte(fmt.Sprintf(`{"id":%d}`, ID))}, nil
+	}
+
+	testCases := []struct {
+		name             string
+		configureMocks   func()
+		expected         idempotency.Response
+		expectedReplayed bool
+		expectedErr      error
+	}{
+		{
+			name: "comment inserted successfully",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
 				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
 
-				commentsMock.On("Insert", mock.Anything, cmt).Return(cmt.ID, nil).Once()
+				keysMock.On("Reserve", mock.Anything, mock.Anything, reserved, mock.Anything).Return(true, nil).Once()
+				commentsMock.On("Insert", mock.Anything, mock.Anything, cmt).Return(cmt.ID, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, cmt.ID, true).Return(cmt, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(cmt, comment.Created)).Return(nil).Once()
+				keysMock.On("Complete", mock.Anything, mock.Anything, scope, key, cmt.ID, resp).Return(nil).Once()
 			},
+			expected: resp,
+		},
+		{
+			name: "request replayed",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				keysMock.On("Reserve", mock.Anything, mock.Anything, reserved, mock.Anything).Return(false, nil).Once()
+				keysMock.On("FindByKey", mock.Anything, mock.Anything, scope, key).Return(idempotency.Key{
+					Scope:       scope,
+					Key:         key,
+					RequestHash: hash,
+					ResourceID:  cmt.ID,
+					Response:    resp,
+				}, nil).Once()
+			},
+			expected:         resp,
+			expectedReplayed: true,
+		},
+		{
+			name: "key reused along another comment",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				keysMock.On("Reserve", mock.Anything, mock.Anything, reserved, mock.Anything).Return(false, nil).Once()
+				keysMock.On("FindByKey", mock.Anything, mock.Anything, scope, key).Return(idempotency.Key{
+					Scope:       scope,
+					Key:         key,
+					RequestHash: "another",
+					ResourceID:  cmt.ID,
+				}, nil).Once()
+			},
+			expectedErr: commentFacade.ErrIdempotencyKeyReused,
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.configureMocks()
 
-			_, err := f.Insert(tc.comment)
+			result, replayed, err := f.InsertIdempotent(context.Background(), cmt, scope, key, respond)
+			test.AssertErrorType(t, err, tc.expectedErr)
+			if diff := cmp.Diff(result, tc.expected); err == nil && diff != "" {
+				t.Errorf("unexpected response %s", diff)
+			}
+			if replayed != tc.expectedReplayed {
+				t.Errorf("unexpected replayed %t", replayed)
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	cmt := fixtures.AnyComment()
+	imp := comment.Import{
+		Source:     "crm",
+		ExternalID: gofakeit.UUID(),
+		Comment:    cmt,
+	}
+	testCases := []struct {
+		name           string
+		configureMocks func()
+		expected       int
+	}{
+		{
+			name: "comment imported successfully",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				importsMock.On("FindCommentID", mock.Anything, mock.Anything, imp.Source, imp.ExternalID).Return(0, repository.ErrNotFound).Once()
+				commentsMock.On("Insert", mock.Anything, mock.Anything, cmt).Return(cmt.ID, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, cmt.ID, true).Return(cmt, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(cmt, comment.Created)).Return(nil).Once()
+				importsMock.On("Insert", mock.Anything, mock.Anything, imp.Source, imp.ExternalID, cmt.ID).Return(nil).Once()
+			},
+			expected: cmt.ID,
+		},
+		{
+			name: "comment already imported",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				importsMock.On("FindCommentID", mock.Anything, mock.Anything, imp.Source, imp.ExternalID).Return(cmt.ID, nil).Once()
+			},
+			expected: cmt.ID,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			ID, err := f.Import(context.Background(), imp)
 			if err != nil {
-				t.Errorf("error inserting comment %s", err)
+				t.Errorf("error importing comment %s", err)
+				return
+			}
+			if ID != tc.expected {
+				t.Errorf("unexpected imported comment id %d", ID)
 			}
 
 			verifyAllMocks(t)
@@ -63,30 +301,42 @@ func TestInsert(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	cmt := fixtures.AnyComment()
+	changedBy := gofakeit.UUID()
+	updated := cmt
+	updated.Version = cmt.Version + 1
 	testCases := []struct {
 		name           string
 		comment        comment.Comment
 		configureMocks func()
+		expected       comment.Comment
 	}{
 		{
 			name:    "comment updated successfully",
 			comment: cmt,
 			configureMocks: func() {
-				txManagerMock.On("Begin").Return(nil, nil, nil).Once()
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
 				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
 
-				commentsMock.On("Update", mock.Anything, cmt).Return(nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, cmt.ID, false).Return(cmt, nil).Once()
+				revisionsMock.On("Insert", mock.Anything, mock.Anything, comment.NewRevision(cmt, comment.RevisionUpdate, changedBy)).Return(1, nil).Once()
+				commentsMock.On("Update", mock.Anything, mock.Anything, cmt).Return(nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, cmt.ID, true).Return(updated, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(updated, comment.Updated)).Return(nil).Once()
 			},
+			expected: updated,
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.configureMocks()
 
-			err := f.Update(tc.comment)
+			updated, err := f.Update(context.Background(), tc.comment, changedBy)
 			if err != nil {
 				t.Errorf("error updating comment %s", err)
 			}
+			if diff := cmp.Diff(updated, tc.expected); diff != "" {
+				t.Errorf("unexpected updated comment %s", diff)
+			}
 
 			verifyAllMocks(t)
 		})
@@ -106,7 +356,7 @@ func TestFindByID(t *testing.T) {
 			ID:       cmt.ID,
 			expected: cmt,
 			configureMocks: func() {
-				commentsMock.On("FindByID", (*sql.Tx)(nil), cmt.ID).Return(cmt, nil).Once()
+				commentsMock.On("FindByID", mock.Anything, (*sql.Tx)(nil), cmt.ID, false).Return(cmt, nil).Once()
 			},
 		},
 	}
@@ -114,7 +364,7 @@ func TestFindByID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.configureMocks()
 
-			result, err := f.FindByID(tc.ID)
+			result, err := f.FindByID(context.Background(), tc.ID, false)
 			if err != nil {
 				t.Errorf("unexpected error finding comment by id %s", err)
 				return
@@ -137,6 +387,15 @@ func TestFind(t *testing.T) {
 		ListingID:    cmt.ListingID,
 	}
 	p, _ := pagination.New(0, 10)
+	skipCount, _ := pagination.FromRequest(httptest.NewRequest(http.MethodGet, "/v1/comment?skipCount=true", nil))
+
+	threaded := q
+	threaded.Threaded = true
+	deletedAt := time.Now()
+	deleted := cmt
+	deleted.DeletedAt = &deletedAt
+	deleted.DeletedBy = gofakeit.UUID()
+	deleted.ReplyCount = 1
 
 	testCases := []struct {
 		name           string
@@ -151,22 +410,50 @@ func TestFind(t *testing.T) {
 			query:      q,
 			pagination: p,
 			configureMocks: func() {
-				commentsMock.On("Find", (*sql.Tx)(nil), q, p).Return([]comment.Comment{
+				commentsMock.On("Find", mock.Anything, (*sql.Tx)(nil), q, p).Return([]comment.Comment{
 					cmt,
 				}, nil).Once()
-				commentsMock.On("Count", (*sql.Tx)(nil), q).Return(1, nil).Once()
+				commentsMock.On("Count", mock.Anything, (*sql.Tx)(nil), q).Return(1, nil).Once()
 			},
 			expected: []comment.Comment{
 				cmt,
 			},
 			expectedCount: 1,
 		},
+		{
+			name:       "some comments found skipping count",
+			query:      q,
+			pagination: skipCount,
+			configureMocks: func() {
+				commentsMock.On("Find", mock.Anything, (*sql.Tx)(nil), q, skipCount).Return([]comment.Comment{
+					cmt,
+				}, nil).Once()
+			},
+			expected: []comment.Comment{
+				cmt,
+			},
+		},
+		{
+			name:       "deleted comments with replies found as tombstones",
+			query:      threaded,
+			pagination: p,
+			configureMocks: func() {
+				commentsMock.On("Find", mock.Anything, (*sql.Tx)(nil), threaded, p).Return([]comment.Comment{
+					deleted,
+				}, nil).Once()
+				commentsMock.On("Count", mock.Anything, (*sql.Tx)(nil), threaded).Return(1, nil).Once()
+			},
+			expected: []comment.Comment{
+				deleted.Tombstone(),
+			},
+			expectedCount: 1,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.configureMocks()
 
-			results, count, err := f.Find(tc.query, tc.pagination)
+			results, count, err := f.Find(context.Background(), tc.query, tc.pagination)
 			if err != nil {
 				t.Errorf("error finding comments %s", err)
 				return
@@ -187,8 +474,74 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestSearch(t *testing.T) {
+	cmt := fixtures.AnyComment()
+
+	q := commentRepository.Query{
+		AdvertiserID: cmt.AdvertiserID,
+		AccountID:    cmt.AccountID,
+		Search:       "fiador",
+	}
+	p, _ := pagination.New(0, 10)
+	result := comment.SearchResult{
+		Comment:   cmt,
+		Rank:      0.1,
+		Highlight: "<mark>fiador</mark>",
+	}
+
+	testCases := []struct {
+		name           string
+		query          commentRepository.Query
+		pagination     pagination.Pagination
+		configureMocks func()
+		expected       []comment.SearchResult
+		expectedCount  int
+	}{
+		{
+			name:       "some comments found",
+			query:      q,
+			pagination: p,
+			configureMocks: func() {
+				commentsMock.On("Search", mock.Anything, (*sql.Tx)(nil), q, p).Return([]comment.SearchResult{
+					result,
+				}, nil).Once()
+				commentsMock.On("Count", mock.Anything, (*sql.Tx)(nil), q).Return(1, nil).Once()
+			},
+			expected: []comment.SearchResult{
+				result,
+			},
+			expectedCount: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			results, count, err := f.Search(context.Background(), tc.query, tc.pagination)
+			if err != nil {
+				t.Errorf("error searching comments %s", err)
+				return
+			}
+
+			if diff := cmp.Diff(results, tc.expected); diff != "" {
+				t.Errorf("unexpected comments %s", diff)
+				return
+			}
+
+			if count != tc.expectedCount {
+				t.Errorf("unexpected comments count %d", count)
+				return
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
 func TestDelete(t *testing.T) {
-	ID := gofakeit.Number(1, 10)
+	cmt := fixtures.AnyComment()
+	ID := cmt.ID
+	changedBy := gofakeit.UUID()
 	testCases := []struct {
 		name           string
 		ID             int
@@ -198,10 +551,14 @@ func TestDelete(t *testing.T) {
 			name: "comment deleted successfully",
 			ID:   ID,
 			configureMocks: func() {
-				txManagerMock.On("Begin").Return(nil, nil, nil).Once()
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
 				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
 
-				commentsMock.On("Delete", mock.Anything, ID).Return(nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, ID, false).Return(cmt, nil).Once()
+				revisionsMock.On("Insert", mock.Anything, mock.Anything, comment.NewRevision(cmt, comment.RevisionDelete, changedBy)).Return(1, nil).Once()
+				commentsMock.On("Delete", mock.Anything, mock.Anything, ID, cmt.Version, changedBy).Return(nil).Once()
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, ID, true).Return(cmt, nil).Once()
+				txManagerMock.On("Publish", mock.Anything, mock.Anything, anyEvent(cmt, comment.Deleted)).Return(nil).Once()
 			},
 		},
 	}
@@ -209,7 +566,7 @@ func TestDelete(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.configureMocks()
 
-			err := f.Delete(tc.ID)
+			err := f.Delete(context.Background(), tc.ID, cmt.Version, changedBy)
 			if err != nil {
 				t.Errorf("error deleting comment %s", err)
 			}
@@ -218,3 +575,172 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestRestore(t *testing.T) {
+	cmt := fixtures.AnyComment()
+	changedBy := gofakeit.UUID()
+	testCases := []struct {
+		name           string
+		ID             int
+		configureMocks func()
+	}{
+		{
+			name: "comment restored successfully",
+			ID:   cmt.ID,
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("FindByID", mock.Anything, mock.Anything, cmt.ID, true).Return(cmt, nil).Once()
+				revisionsMock.On("Insert", mock.Anything, mock.Anything, comment.NewRevision(cmt, comment.RevisionRestore, changedBy)).Return(1, nil).Once()
+				commentsMock.On("Restore", mock.Anything, mock.Anything, cmt.ID).Return(nil).Once()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			err := f.Restore(context.Background(), tc.ID, changedBy)
+			if err != nil {
+				t.Errorf("error restoring comment %s", err)
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	before := time.Now()
+	testCases := []struct {
+		name           string
+		configureMocks func()
+		expected       int
+	}{
+		{
+			name: "comments, revisions, attachments, imports and idempotency keys purged",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("Purge", mock.Anything, mock.Anything, before).Return([]int{1, 2}, nil).Once()
+				revisionsMock.On("DeleteByComments", mock.Anything, mock.Anything, []int{1, 2}).Return(nil).Once()
+				importsMock.On("DeleteByComments", mock.Anything, mock.Anything, []int{1, 2}).Return(nil).Once()
+				keysMock.On("DeleteByResources", mock.Anything, mock.Anything, "comment:", []int{1, 2}).Return(nil).Once()
+				attachmentsMock.On("DeleteByComments", mock.Anything, mock.Anything, []int{1, 2}).Return([]string{"comment/1/a"}, nil).Once()
+				storageMock.On("Delete", mock.Anything, []string{"comment/1/a"}).Return(nil).Once()
+			},
+			expected: 2,
+		},
+		{
+			name: "comments without attachments purged",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("Purge", mock.Anything, mock.Anything, before).Return([]int{3}, nil).Once()
+				revisionsMock.On("DeleteByComments", mock.Anything, mock.Anything, []int{3}).Return(nil).Once()
+				importsMock.On("DeleteByComments", mock.Anything, mock.Anything, []int{3}).Return(nil).Once()
+				keysMock.On("DeleteByResources", mock.Anything, mock.Anything, "comment:", []int{3}).Return(nil).Once()
+				attachmentsMock.On("DeleteByComments", mock.Anything, mock.Anything, []int{3}).Return([]string{}, nil).Once()
+			},
+			expected: 1,
+		},
+		{
+			name: "nothing to purge",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything, mock.Anything).Once()
+
+				commentsMock.On("Purge", mock.Anything, mock.Anything, before).Return([]int{}, nil).Once()
+			},
+			expected: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			count, err := f.Purge(context.Background(), before)
+			if err != nil {
+				t.Errorf("error purging comments %s", err)
+				return
+			}
+			if count != tc.expected {
+				t.Errorf("unexpected purged comments count %d", count)
+				return
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
+func TestFindRevisions(t *testing.T) {
+	cmt := fixtures.AnyComment()
+	rev := comment.NewRevision(cmt, comment.RevisionUpdate, gofakeit.UUID())
+	p, _ := pagination.New(0, 10)
+	skipCount, _ := pagination.FromRequest(httptest.NewRequest(http.MethodGet, "/v1/comment/1/revisions?skipCount=true", nil))
+
+	testCases := []struct {
+		name           string
+		commentID      int
+		pagination     pagination.Pagination
+		configureMocks func()
+		expected       []comment.Revision
+		expectedCount  int
+	}{
+		{
+			name:       "some revisions found",
+			commentID:  cmt.ID,
+			pagination: p,
+			configureMocks: func() {
+				revisionsMock.On("Find", mock.Anything, (*sql.Tx)(nil), cmt.ID, p).Return([]comment.Revision{
+					rev,
+				}, nil).Once()
+				revisionsMock.On("Count", mock.Anything, (*sql.Tx)(nil), cmt.ID).Return(1, nil).Once()
+			},
+			expected: []comment.Revision{
+				rev,
+			},
+			expectedCount: 1,
+		},
+		{
+			name:       "some revisions found without counting them",
+			commentID:  cmt.ID,
+			pagination: skipCount,
+			configureMocks: func() {
+				revisionsMock.On("Find", mock.Anything, (*sql.Tx)(nil), cmt.ID, skipCount).Return([]comment.Revision{
+					rev,
+				}, nil).Once()
+			},
+			expected: []comment.Revision{
+				rev,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			results, count, err := f.FindRevisions(context.Background(), tc.commentID, tc.pagination)
+			if err != nil {
+				t.Errorf("error finding comment revisions %s", err)
+				return
+			}
+
+			if diff := cmp.Diff(results, tc.expected); diff != "" {
+				t.Errorf("unexpected comment revisions %s", diff)
+				return
+			}
+
+			if count != tc.expectedCount {
+				t.Errorf("unexpected comment revisions count %d", count)
+				return
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
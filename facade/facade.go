@@ -1,43 +1,103 @@
 package facade
 
 import (
+	"context"
 	sql "database/sql"
+	"go-boilerplate/common"
+	"go-boilerplate/domain/outbox"
 	"go-boilerplate/repository"
+	outboxRepository "go-boilerplate/repository/outbox"
+	"sync"
+	"time"
 )
 
-var txManager = &TxManagerImpl{}
+var (
+	relayTimeout = time.Duration(common.Config.GetInt64("outboxRelayTimeoutSeconds")) * time.Second
+
+	txManager = &TxManagerImpl{
+		Events:  outboxRepository.Get(),
+		pending: map[*sql.Tx]pendingEvents{},
+	}
+)
 
 // TxManager for business logic in facade layer
 type TxManager interface {
 	// Begin a transaction with database and message buffer
-	Begin() (*sql.Tx, error)
-	// Resolve given transaction handling message buffer after commit succeeds
+	Begin(ctx context.Context) (*sql.Tx, error)
+	// Publish an event recording it in the outbox within the given transaction and buffering it to be relayed after commit
+	Publish(ctx context.Context, tx *sql.Tx, e outbox.Event) error
+	// Resolve given transaction handling message buffer after commit succeeds, a commit failure is set to the given error
 	Resolve(*sql.Tx, *error)
 }
 
-type TxManagerImpl struct{}
+type TxManagerImpl struct {
+	Events outboxRepository.Repository
+
+	mu      sync.Mutex
+	pending map[*sql.Tx]pendingEvents
+	// relays running in background after commit
+	relays sync.WaitGroup
+}
+
+// pendingEvents published within a transaction, with the context they were published from to correlate their relay with it
+type pendingEvents struct {
+	ctx context.Context
+	IDs []int
+}
 
 // GetTxManager instance
 func GetTxManager() TxManager {
 	return txManager
 }
 
+// DrainRelays waits for the events of committed transactions being relayed in background, up to the context deadline.
+// It's meant to be called on shutdown, events not relayed by then are left pending in the outbox
+func DrainRelays(ctx context.Context) error {
+	return txManager.Drain(ctx)
+}
+
+// Resolve commits or rolls back the given transaction, events published within a committed one are relayed in background.
+// A commit failure is set to the given error instead of panicking, as it may happen while relaying in background.
+// Events which fail to be relayed are left pending in the outbox to be retried by the outbox relay command
 func (t *TxManagerImpl) Resolve(tx *sql.Tx, err *error) {
+	pending := t.flush(tx)
 	if p := recover(); p != nil {
 		tx.Rollback()
 		panic(p)
 	} else if *err != nil {
 		tx.Rollback()
 	} else {
-		err := tx.Commit()
-		if err != nil {
-			panic(err)
+		if *err = tx.Commit(); *err != nil {
+			return
+		}
+		if len(pending.IDs) > 0 {
+			t.relays.Add(1)
+			go func() {
+				defer t.relays.Done()
+				t.relay(pending)
+			}()
 		}
 	}
 }
 
-func (t *TxManagerImpl) Begin() (*sql.Tx, error) {
-	tx, err := repository.DB.Begin()
+// Drain waits for the relays running in background, up to the context deadline
+func (t *TxManagerImpl) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.relays.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *TxManagerImpl) Begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := repository.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +105,46 @@ func (t *TxManagerImpl) Begin() (*sql.Tx, error) {
 	return tx, nil
 }
 
-// WithTxManager execute given func in a transactional context
-func WithTxManager(txm TxManager, fn func(tx *sql.Tx) error) error {
-	tx, err := txm.Begin()
+func (t *TxManagerImpl) Publish(ctx context.Context, tx *sql.Tx, e outbox.Event) error {
+	ID, err := t.Events.Insert(ctx, tx, e)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending[tx]
+	pending.ctx = ctx
+	pending.IDs = append(pending.IDs, ID)
+	t.pending[tx] = pending
+
+	return nil
+}
+
+// flush the message buffer of the given transaction returning its events
+func (t *TxManagerImpl) flush(tx *sql.Tx) pendingEvents {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending := t.pending[tx]
+	delete(t.pending, tx)
+	return pending
+}
+
+// relay the given events in a context outliving the one they were published from, but still correlated with it
+func (t *TxManagerImpl) relay(pending pendingEvents) {
+	ctx, cancel := context.WithTimeout(common.DetachedContext(pending.ctx), relayTimeout)
+	defer cancel()
+
+	_, err := GetOutboxRelay().Relay(ctx, pending.IDs)
+	if err != nil {
+		common.HandleErrorContext(ctx, "error relaying outbox events after commit", err)
+	}
+}
+
+// WithTxManager execute given func in a transactional context, returning its error or the commit one
+func WithTxManager(ctx context.Context, txm TxManager, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := txm.Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,27 @@
+// Package idempotency holds business logic of the idempotency keys sent by clients along creation requests
+package idempotency
+
+import (
+	"context"
+	idempotencyRepository "go-boilerplate/repository/idempotency"
+	"time"
+)
+
+var (
+	instance = &Facade{
+		Keys: idempotencyRepository.Get(),
+	}
+)
+
+type Facade struct {
+	Keys idempotencyRepository.Repository
+}
+
+func Get() *Facade {
+	return instance
+}
+
+// Purge deletes idempotency keys created before the given time, their requests can't be replayed anymore
+func (f *Facade) Purge(ctx context.Context, before time.Time) (int, error) {
+	return f.Keys.Purge(ctx, nil, before)
+}
@@ -3,8 +3,12 @@
 package facade
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
+	outbox "go-boilerplate/domain/outbox"
+
 	sql "database/sql"
 )
 
@@ -13,30 +17,44 @@ type MockTxManager struct {
 	mock.Mock
 }
 
-// Begin provides a mock function with given fields:
-func (_m *MockTxManager) Begin() (*sql.Tx, error) {
-	ret := _m.Called()
+// Begin provides a mock function with given fields: ctx
+func (_m *MockTxManager) Begin(ctx context.Context) (*sql.Tx, error) {
+	ret := _m.Called(ctx)
 
 	var r0 *sql.Tx
-	if rf, ok := ret.Get(0).(func() *sql.Tx); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) *sql.Tx); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*sql.Tx)
 		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func() error); ok {
-		r2 = rf()
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Publish provides a mock function with given fields: ctx, tx, e
+func (_m *MockTxManager) Publish(ctx context.Context, tx *sql.Tx, e outbox.Event) error {
+	ret := _m.Called(ctx, tx, e)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, outbox.Event) error); ok {
+		r0 = rf(ctx, tx, e)
 	} else {
-		r2 = ret.Error(2)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r2
+	return r0
 }
 
-// Resolve provides a mock function with given fields: _a0, _a2
-func (_m *MockTxManager) Resolve(_a0 *sql.Tx, _a2 *error) {
-	_m.Called(_a0, _a2)
+// Resolve provides a mock function with given fields: _a0, _a1
+func (_m *MockTxManager) Resolve(_a0 *sql.Tx, _a1 *error) {
+	_m.Called(_a0, _a1)
 }
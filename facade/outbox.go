@@ -0,0 +1,98 @@
+package facade
+
+import (
+	"context"
+	sql "database/sql"
+	"encoding/json"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/domain/outbox"
+	outboxRepository "go-boilerplate/repository/outbox"
+	"go-boilerplate/repository/queue"
+	"time"
+)
+
+var outboxRelay = &OutboxRelay{
+	TxManager:   txManager,
+	Events:      outboxRepository.Get(),
+	Publisher:   queue.Get(),
+	BatchSize:   common.Config.GetInt("outboxBatchSize"),
+	MaxAttempts: common.Config.GetInt("outboxMaxAttempts"),
+	Backoff:     time.Duration(common.Config.GetInt64("outboxBackoffSeconds")) * time.Second,
+	MaxBackoff:  time.Duration(common.Config.GetInt64("outboxMaxBackoffSeconds")) * time.Second,
+}
+
+// OutboxRelay publishes events recorded in the outbox to the queue, at least once.
+// Failed events are retried with an exponential backoff until they reach the maximum attempts, then they are dead
+type OutboxRelay struct {
+	TxManager   TxManager
+	Events      outboxRepository.Repository
+	Publisher   queue.Publisher
+	BatchSize   int
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// GetOutboxRelay instance
+func GetOutboxRelay() *OutboxRelay {
+	return outboxRelay
+}
+
+// Relay a batch of pending events, limited to the given IDs when they are set, returning how many were published.
+// Events are locked while they're published so concurrent relays skip them
+func (o *OutboxRelay) Relay(ctx context.Context, IDs []int) (published int, err error) {
+	err = WithTxManager(ctx, o.TxManager, func(tx *sql.Tx) error {
+		events, err := o.Events.FindPending(ctx, tx, IDs, o.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		publishedIDs := []int{}
+		for _, e := range events {
+			err := o.publish(ctx, e)
+			if err == nil {
+				publishedIDs = append(publishedIDs, e.ID)
+				continue
+			}
+
+			failed := e.Failed(err, o.MaxAttempts, o.Backoff, o.MaxBackoff, time.Now())
+			if failed.Status == outbox.Dead {
+				common.HandleError(fmt.Sprintf("outbox event %d is dead after %d attempts", e.ID, failed.Attempts), err)
+			}
+			err = o.Events.MarkFailed(ctx, tx, failed)
+			if err != nil {
+				return err
+			}
+		}
+
+		published = len(publishedIDs)
+		if published == 0 {
+			return nil
+		}
+
+		return o.Events.MarkPublished(ctx, tx, publishedIDs)
+	})
+
+	return
+}
+
+// Purge deletes events published before the given time, returning how many were deleted
+func (o *OutboxRelay) Purge(ctx context.Context, before time.Time) (int, error) {
+	return o.Events.Purge(ctx, nil, before)
+}
+
+func (o *OutboxRelay) publish(ctx context.Context, e outbox.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return o.Publisher.Publish(ctx, queue.Message{
+		Body: string(body),
+		Attributes: map[string]string{
+			"aggregate": e.Aggregate,
+			"type":      e.Type,
+		},
+	})
+}
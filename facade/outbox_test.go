@@ -0,0 +1,170 @@
+package facade_test
+
+import (
+	"context"
+	sql "database/sql"
+	"encoding/json"
+	"errors"
+	"go-boilerplate/domain/outbox"
+	"go-boilerplate/facade"
+	outboxRepository "go-boilerplate/repository/outbox"
+	"go-boilerplate/repository/queue"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	txManagerMock = &facade.MockTxManager{}
+	eventsMock    = &outboxRepository.MockRepository{}
+	publisherMock = &queue.MockPublisher{}
+	relay         = facade.OutboxRelay{
+		TxManager:   txManagerMock,
+		Events:      eventsMock,
+		Publisher:   publisherMock,
+		BatchSize:   100,
+		MaxAttempts: 3,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+	}
+	verifyAllMocks = func(t *testing.T) {
+		txManagerMock.AssertExpectations(t)
+		eventsMock.AssertExpectations(t)
+		publisherMock.AssertExpectations(t)
+	}
+)
+
+func TestRelay(t *testing.T) {
+	event := outbox.Event{
+		ID:          1,
+		Aggregate:   "comment",
+		AggregateID: 10,
+		Type:        "CommentCreated",
+		Payload:     json.RawMessage(`{"id":10}`),
+		Status:      outbox.Pending,
+	}
+	body, _ := json.Marshal(event)
+	message := queue.Message{
+		Body: string(body),
+		Attributes: map[string]string{
+			"aggregate": "comment",
+			"type":      "CommentCreated",
+		},
+	}
+	lastAttempt := event
+	lastAttempt.Attempts = 2
+	publishErr := errors.New("queue unavailable")
+
+	testCases := []struct {
+		name              string
+		IDs               []int
+		configureMocks    func()
+		expectedPublished int
+	}{
+		{
+			name: "events published",
+			IDs:  []int{event.ID},
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything).Once()
+
+				eventsMock.On("FindPending", mock.Anything, mock.Anything, []int{event.ID}, 100).Return([]outbox.Event{event}, nil).Once()
+				publisherMock.On("Publish", mock.Anything, message).Return(nil).Once()
+				eventsMock.On("MarkPublished", mock.Anything, mock.Anything, []int{event.ID}).Return(nil).Once()
+			},
+			expectedPublished: 1,
+		},
+		{
+			name: "no pending events",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything).Once()
+
+				eventsMock.On("FindPending", mock.Anything, mock.Anything, []int(nil), 100).Return([]outbox.Event{}, nil).Once()
+			},
+		},
+		{
+			name: "failed event retried later",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything).Once()
+
+				eventsMock.On("FindPending", mock.Anything, mock.Anything, []int(nil), 100).Return([]outbox.Event{event}, nil).Once()
+				publisherMock.On("Publish", mock.Anything, message).Return(publishErr).Once()
+				eventsMock.On("MarkFailed", mock.Anything, mock.Anything, mock.MatchedBy(func(e outbox.Event) bool {
+					return e.ID == event.ID && e.Status == outbox.Pending && e.Attempts == 1 && e.AvailableAt.After(time.Now())
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "failed event dead after maximum attempts",
+			configureMocks: func() {
+				txManagerMock.On("Begin", mock.Anything).Return(nil, nil).Once()
+				txManagerMock.On("Resolve", mock.Anything, mock.Anything).Once()
+
+				eventsMock.On("FindPending", mock.Anything, mock.Anything, []int(nil), 100).Return([]outbox.Event{lastAttempt}, nil).Once()
+				publisherMock.On("Publish", mock.Anything, message).Return(publishErr).Once()
+				eventsMock.On("MarkFailed", mock.Anything, mock.Anything, mock.MatchedBy(func(e outbox.Event) bool {
+					return e.ID == event.ID && e.Status == outbox.Dead && e.Attempts == 3 && e.LastError == publishErr.Error()
+				})).Return(nil).Once()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.configureMocks()
+
+			published, err := relay.Relay(context.Background(), tc.IDs)
+			if err != nil {
+				t.Errorf("unexpected error relaying events %s", err)
+				return
+			}
+			if published != tc.expectedPublished {
+				t.Errorf("unexpected published events %d", published)
+			}
+
+			verifyAllMocks(t)
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	before := time.Now()
+	eventsMock.On("Purge", mock.Anything, mock.Anything, before).Return(2, nil).Once()
+
+	count, err := relay.Purge(context.Background(), before)
+	if err != nil {
+		t.Errorf("unexpected error purging events %s", err)
+		return
+	}
+	if count != 2 {
+		t.Errorf("unexpected purged events %d", count)
+	}
+
+	verifyAllMocks(t)
+}
+
+func TestDrainRelays(t *testing.T) {
+	err := facade.DrainRelays(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error draining relays without any running %s", err)
+	}
+}
+
+func TestWithTxManagerCommitError(t *testing.T) {
+	commitErr := errors.New("connection reset")
+	txManagerMock.On("Begin", mock.Anything).Return(nil, nil).Once()
+	txManagerMock.On("Resolve", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*error) = commitErr
+	}).Once()
+
+	err := facade.WithTxManager(context.Background(), txManagerMock, func(tx *sql.Tx) error {
+		return nil
+	})
+	if !errors.Is(err, commitErr) {
+		t.Errorf("expected commit error but got %v", err)
+	}
+
+	verifyAllMocks(t)
+}
@@ -0,0 +1,48 @@
+// Package ratelimit holds rate limiting business logic, limits are applied per client and purged once idle
+package ratelimit
+
+import (
+	"context"
+	"go-boilerplate/common"
+	"go-boilerplate/domain/ratelimit"
+	ratelimitRepository "go-boilerplate/repository/ratelimit"
+	"time"
+)
+
+var (
+	instance = &Facade{
+		Store: ratelimitRepository.Get(),
+	}
+)
+
+type Facade struct {
+	Store ratelimitRepository.Store
+}
+
+func Get() *Facade {
+	return instance
+}
+
+// Allow a request of the client of the given key following the given limit, taking a token of its bucket
+func (f *Facade) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return f.Store.Take(ctx, key, limit, time.Now())
+}
+
+// Watch purges buckets idle for longer than the given idle time periodically until the given context is done,
+// a bucket idle for longer than the period of its limit is full again so purging it doesn't change its limit
+func (f *Facade) Watch(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := f.Store.Purge(ctx, time.Now().Add(-idle))
+			if err != nil && ctx.Err() == nil {
+				common.HandleError("error purging rate limit buckets", err)
+			}
+		}
+	}
+}
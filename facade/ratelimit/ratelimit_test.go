@@ -0,0 +1,56 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"go-boilerplate/domain/ratelimit"
+	ratelimitFacade "go-boilerplate/facade/ratelimit"
+	ratelimitRepository "go-boilerplate/repository/ratelimit"
+	"go-boilerplate/test"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAllow(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 10, Period: time.Minute}
+	storeErr := errors.New("store unavailable")
+
+	testCases := []struct {
+		name          string
+		result        ratelimit.Result
+		err           error
+		expected      ratelimit.Result
+		expectedError error
+	}{
+		{
+			name:     "request allowed",
+			result:   ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second},
+			expected: ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second},
+		},
+		{
+			name:          "store failed",
+			err:           storeErr,
+			expectedError: storeErr,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			storeMock := &ratelimitRepository.MockStore{}
+			storeMock.On("Take", mock.Anything, "GET /v1/comment|ip:127.0.0.1", limit, mock.Anything).Return(tc.result, tc.err).Once()
+			f := ratelimitFacade.Facade{
+				Store: storeMock,
+			}
+
+			result, err := f.Allow(context.Background(), "GET /v1/comment|ip:127.0.0.1", limit)
+			test.AssertErrorType(t, err, tc.expectedError)
+			if diff := cmp.Diff(result, tc.expected); diff != "" {
+				t.Errorf("unexpected result %s", diff)
+			}
+
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
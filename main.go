@@ -4,8 +4,6 @@ import (
 	"go-boilerplate/cmd"
 	"go-boilerplate/common"
 	"go-boilerplate/repository"
-	"os"
-	"time"
 
 	"github.com/getsentry/sentry-go"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -14,11 +12,9 @@ import (
 func main() {
 	if common.Config.Get("datadogEnabled") == "true" {
 		tracer.Start(tracer.WithRuntimeMetrics())
-		defer tracer.Stop()
 	}
 
 	if common.Config.Get("environment") == "prod" {
-		defer sentry.Flush(time.Second * 5)
 		sentry.Init(sentry.ClientOptions{
 			Dsn:   common.Config.Get("sentryDsn"),
 			Debug: false,
@@ -28,8 +24,7 @@ func main() {
 	err := repository.Setup()
 	if err != nil {
 		common.HandleError("error on repository setup", err)
-		sentry.Flush(time.Second * 2)
-		os.Exit(-1)
+		cmd.Exit(-1)
 	}
 
 	cmd.Execute()
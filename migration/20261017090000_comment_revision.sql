@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE comment_revision (
+  id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  comment_id bigint NOT NULL,
+  action character varying NOT NULL,
+  type character varying NOT NULL,
+  description character varying NOT NULL,
+  changed_by character varying NOT NULL,
+  created_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX comment_revision_comment_id_created_at ON comment_revision USING btree (comment_id, created_at);
+
+-- +goose Down
+DROP TABLE comment_revision;
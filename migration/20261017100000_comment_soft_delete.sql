@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE comment ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE comment ADD COLUMN deleted_by character varying;
+
+CREATE INDEX comment_deleted_at ON comment USING btree (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX comment_deleted_at;
+ALTER TABLE comment DROP COLUMN deleted_by;
+ALTER TABLE comment DROP COLUMN deleted_at;
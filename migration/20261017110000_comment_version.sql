@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE comment ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE comment DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX comment_advertiser_id_account_id_created_at_id ON comment USING btree (advertiser_id, account_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX comment_advertiser_id_account_id_created_at_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TEXT SEARCH CONFIGURATION portuguese_unaccent (COPY = portuguese);
+ALTER TEXT SEARCH CONFIGURATION portuguese_unaccent
+  ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+
+ALTER TABLE comment ADD COLUMN description_tsv tsvector
+  GENERATED ALWAYS AS (to_tsvector('portuguese_unaccent', description)) STORED;
+
+CREATE INDEX comment_description_tsv ON comment USING gin (description_tsv);
+
+-- +goose Down
+DROP INDEX comment_description_tsv;
+ALTER TABLE comment DROP COLUMN description_tsv;
+DROP TEXT SEARCH CONFIGURATION portuguese_unaccent;
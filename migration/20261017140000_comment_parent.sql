@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE comment ADD COLUMN parent_id bigint REFERENCES comment (id);
+ALTER TABLE comment ADD COLUMN depth smallint NOT NULL DEFAULT 0;
+
+CREATE INDEX comment_parent_id ON comment USING btree (parent_id);
+
+-- +goose Down
+DROP INDEX comment_parent_id;
+ALTER TABLE comment DROP COLUMN depth;
+ALTER TABLE comment DROP COLUMN parent_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE comment_attachment (
+  id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  comment_id bigint NOT NULL,
+  file_name character varying NOT NULL,
+  content_type character varying NOT NULL,
+  size bigint NOT NULL,
+  storage_key character varying NOT NULL,
+  created_by character varying NOT NULL,
+  created_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX comment_attachment_comment_id_created_at ON comment_attachment USING btree (comment_id, created_at);
+
+-- +goose Down
+DROP TABLE comment_attachment;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE outbox (
+  id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  aggregate character varying NOT NULL,
+  aggregate_id bigint NOT NULL,
+  type character varying NOT NULL,
+  payload jsonb NOT NULL,
+  status character varying NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error character varying,
+  available_at timestamp with time zone NOT NULL,
+  created_at timestamp with time zone NOT NULL,
+  published_at timestamp with time zone
+);
+
+CREATE INDEX outbox_pending_available_at ON outbox USING btree (available_at, id) WHERE status = 'PENDING';
+CREATE INDEX outbox_published_at ON outbox USING btree (published_at) WHERE status = 'PUBLISHED';
+
+-- +goose Down
+DROP TABLE outbox;
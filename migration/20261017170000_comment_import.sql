@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE comment_import (
+  source character varying NOT NULL,
+  external_id character varying NOT NULL,
+  comment_id bigint NOT NULL,
+  created_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (source, external_id)
+);
+
+-- +goose Down
+DROP TABLE comment_import;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE rate_limit (
+  key character varying PRIMARY KEY,
+  tokens double precision NOT NULL,
+  updated_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX rate_limit_updated_at ON rate_limit USING btree (updated_at);
+
+-- +goose Down
+DROP TABLE rate_limit;
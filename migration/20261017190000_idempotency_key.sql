@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE idempotency_key (
+  scope character varying NOT NULL,
+  key character varying NOT NULL,
+  request_hash character varying NOT NULL,
+  resource_id bigint,
+  response_status integer,
+  response_body bytea,
+  created_at timestamp with time zone NOT NULL,
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_key_created_at ON idempotency_key USING btree (created_at);
+
+-- +goose Down
+DROP TABLE idempotency_key;
@@ -0,0 +1,106 @@
+// Package advertiser holds access logic of advertisers configuration kept in S3
+package advertiser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/domain/advertiser"
+	"go-boilerplate/repository"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+var (
+	instance = &repositoryImpl{
+		bucket: common.Config.Get("advertiserConfigBucket"),
+		key:    common.Config.Get("advertiserConfigKey"),
+	}
+)
+
+// Repository to enable this repository to be mocked
+type Repository interface {
+	// Load the current version of the configs
+	Load(ctx context.Context) (advertiser.Configs, error)
+	// LoadVersion of the configs kept in the history
+	LoadVersion(ctx context.Context, version int) (advertiser.Configs, error)
+	// Save the configs as the current version, keeping a copy of it in the history
+	Save(ctx context.Context, configs advertiser.Configs) error
+}
+
+type repositoryImpl struct {
+	bucket string
+	key    string
+}
+
+// Get this repository instance
+func Get() Repository {
+	return instance
+}
+
+func (r *repositoryImpl) Load(ctx context.Context) (advertiser.Configs, error) {
+	return r.load(ctx, r.key)
+}
+
+func (r *repositoryImpl) LoadVersion(ctx context.Context, version int) (advertiser.Configs, error) {
+	return r.load(ctx, r.historyKey(version))
+}
+
+func (r *repositoryImpl) Save(ctx context.Context, configs advertiser.Configs) error {
+	body, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+
+	// history first, so every current version can be found in it
+	err = r.put(ctx, r.historyKey(configs.Version), body)
+	if err != nil {
+		return err
+	}
+
+	return r.put(ctx, r.key, body)
+}
+
+func (r *repositoryImpl) load(ctx context.Context, key string) (advertiser.Configs, error) {
+	output, err := s3.New(repository.AWSSession).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return advertiser.Configs{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return advertiser.Configs{}, err
+	}
+	defer output.Body.Close()
+
+	configs := advertiser.Configs{}
+	err = json.NewDecoder(output.Body).Decode(&configs)
+	if err != nil {
+		return advertiser.Configs{}, fmt.Errorf("error decoding advertiser configs %s: %w", key, err)
+	}
+
+	return configs, nil
+}
+
+func (r *repositoryImpl) put(ctx context.Context, key string, body []byte) error {
+	_, err := s3.New(repository.AWSSession).PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(r.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+// historyKey of the given version, next to the current configs (eg history/dev-configs/42.json)
+func (r *repositoryImpl) historyKey(version int) string {
+	return fmt.Sprintf("history/%s/%d.json", strings.TrimSuffix(r.key, ".json"), version)
+}
@@ -0,0 +1,67 @@
+package advertiser_test
+
+import (
+	"context"
+	"go-boilerplate/domain"
+	"go-boilerplate/domain/advertiser"
+	"go-boilerplate/repository"
+	advertiserRepository "go-boilerplate/repository/advertiser"
+	"go-boilerplate/test"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+var impl = advertiserRepository.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	current, err := impl.Load(ctx)
+	if err != nil {
+		t.Errorf("unexpected error loading advertiser configs %s", err)
+		return
+	}
+
+	cfg := advertiser.Config{
+		AdvertiserID: gofakeit.UUID(),
+		Portals:      []advertiser.PortalSettings{{Portal: domain.PortalVivaReal, Enabled: true}},
+	}
+	next := current.With(cfg, gofakeit.UUID(), time.Now().UTC().Truncate(time.Second))
+	err = impl.Save(ctx, next)
+	if err != nil {
+		t.Errorf("unexpected error saving advertiser configs %s", err)
+		return
+	}
+
+	result, err := impl.Load(ctx)
+	if err != nil {
+		t.Errorf("unexpected error loading advertiser configs %s", err)
+		return
+	}
+	if diff := cmp.Diff(result, next); diff != "" {
+		t.Errorf("unexpected current advertiser configs %s", diff)
+	}
+
+	result, err = impl.LoadVersion(ctx, next.Version)
+	if err != nil {
+		t.Errorf("unexpected error loading advertiser configs version %s", err)
+		return
+	}
+	if diff := cmp.Diff(result, next); diff != "" {
+		t.Errorf("unexpected advertiser configs version %s", diff)
+	}
+
+	_, err = impl.LoadVersion(ctx, -1)
+	test.AssertErrorType(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"errors"
+	"go-boilerplate/common"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CircuitState of the circuit breaker of a host
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through, counting consecutive failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests until the open period is over
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through, closing the circuit when it succeeds
+	CircuitHalfOpen
+)
+
+var circuitStates = [...]string{"CLOSED", "OPEN", "HALF_OPEN"}
+
+func (s CircuitState) String() string {
+	return circuitStates[s]
+}
+
+// ErrCircuitOpen is when a request isn't executed because the circuit of its host is open
+var ErrCircuitOpen = errors.New("circuit open")
+
+// HTTPPolicy of resilience of the requests to a host
+type HTTPPolicy struct {
+	// MaxRetries of a failed request, only idempotent requests are retried
+	MaxRetries int
+	// Backoff before the first retry, doubled on every retry
+	Backoff time.Duration
+	// MaxBackoff between retries, Retry-After headers are capped by it
+	MaxBackoff time.Duration
+	// FailureThreshold of consecutive failures opening the circuit
+	FailureThreshold int
+	// OpenTimeout the circuit is kept open before letting a probe request through
+	OpenTimeout time.Duration
+}
+
+// DefaultHTTPPolicy of the hosts without a policy of their own
+var DefaultHTTPPolicy = HTTPPolicy{
+	MaxRetries:       common.Config.GetInt("httpMaxRetries"),
+	Backoff:          time.Duration(common.Config.GetInt("httpBackoffMilliseconds")) * time.Millisecond,
+	MaxBackoff:       time.Duration(common.Config.GetInt("httpMaxBackoffSeconds")) * time.Second,
+	FailureThreshold: common.Config.GetInt("httpBreakerFailureThreshold"),
+	OpenTimeout:      time.Duration(common.Config.GetInt("httpBreakerOpenSeconds")) * time.Second,
+}
+
+var (
+	policiesMu sync.RWMutex
+	policies   = map[string]HTTPPolicy{}
+	breakers   = map[string]*circuitBreaker{}
+)
+
+// SetHTTPPolicy of the requests to the given host (eg fredo.vivareal.com), resetting its circuit
+func SetHTTPPolicy(host string, policy HTTPPolicy) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+
+	policies[host] = policy
+	delete(breakers, host)
+}
+
+func httpPolicy(host string) HTTPPolicy {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+
+	policy, ok := policies[host]
+	if !ok {
+		return DefaultHTTPPolicy
+	}
+	return policy
+}
+
+func hostBreaker(host string) *circuitBreaker {
+	policiesMu.RLock()
+	b, ok := breakers[host]
+	policiesMu.RUnlock()
+	if ok {
+		return b
+	}
+
+	policy := httpPolicy(host)
+
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	b, ok = breakers[host]
+	if !ok {
+		b = &circuitBreaker{policy: policy}
+		breakers[host] = b
+	}
+	return b
+}
+
+// circuitStatus of the hosts whose circuit isn't closed
+func circuitStatus() map[string]string {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+
+	now := time.Now()
+	status := map[string]string{}
+	for host, b := range breakers {
+		if state := b.currentState(now); state != CircuitClosed {
+			status[host] = state.String()
+		}
+	}
+	return status
+}
+
+// circuitBreaker of a host, opened after a number of consecutive failures
+type circuitBreaker struct {
+	policy HTTPPolicy
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow a request through, failing with ErrCircuitOpen while the circuit is open or probing
+func (b *circuitBreaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.policy.OpenTimeout {
+		b.state = CircuitHalfOpen
+		b.probing = false
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+
+	return nil
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *circuitBreaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.policy.FailureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = now
+		b.probing = false
+	}
+}
+
+// release the probe of a half open circuit without an outcome, eg when the caller canceled it
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) currentState(now time.Time) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.policy.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// backoff before the given retry, exponential with jitter, unless the response asks for a Retry-After
+func (p HTTPPolicy) backoff(retry int, response *http.Response) time.Duration {
+	if wait, ok := retryAfter(response, time.Now()); ok {
+		if wait > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return wait
+	}
+
+	wait := p.Backoff << (retry - 1)
+	if wait <= 0 || wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	// equal jitter, so concurrent clients don't retry in lockstep
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter of the response, in seconds or as an http date
+func retryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	header := response.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// retryableStatus of responses worth retrying, which also count as failures of the host
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+// Package attachment holds data access logic of comment attachments
+package attachment
+
+import (
+	"context"
+	sql "database/sql"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/repository"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	columns = `
+		id,
+		comment_id,
+		file_name,
+		content_type,
+		size,
+		storage_key,
+		created_by,
+		created_at
+	`
+)
+
+var (
+	instance = &repositoryImpl{}
+)
+
+// Repository to enable this repository to be mocked
+type Repository interface {
+	// Insert a comment attachment
+	Insert(ctx context.Context, tx *sql.Tx, att comment.Attachment) (int, error)
+	// FindByID a comment attachment
+	FindByID(ctx context.Context, tx *sql.Tx, ID int) (comment.Attachment, error)
+	// Find attachments of a given comment
+	Find(ctx context.Context, tx *sql.Tx, commentID int, p pagination.Pagination) ([]comment.Attachment, error)
+	// Count attachments of a given comment
+	Count(ctx context.Context, tx *sql.Tx, commentID int) (int, error)
+	// DeleteByComments deletes all attachments of the given comments returning their storage keys
+	DeleteByComments(ctx context.Context, tx *sql.Tx, commentIDs []int) ([]string, error)
+}
+
+type repositoryImpl struct{}
+
+// Get this repository instance
+func Get() Repository {
+	return instance
+}
+
+func (r *repositoryImpl) Insert(ctx context.Context, tx *sql.Tx, att comment.Attachment) (int, error) {
+	insert, values, err := repository.Psq.Insert("comment_attachment").Columns(`
+		comment_id,
+		file_name,
+		content_type,
+		size,
+		storage_key,
+		created_by,
+		created_at
+	`).Values(
+		att.CommentID,
+		att.FileName,
+		att.ContentType,
+		att.Size,
+		att.Key,
+		att.CreatedBy,
+		time.Now(),
+	).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	ID := 0
+	err = tx.QueryRowContext(ctx, insert, values...).Scan(&ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, nil
+}
+
+func (r *repositoryImpl) FindByID(ctx context.Context, tx *sql.Tx, ID int) (comment.Attachment, error) {
+	query, values, err := repository.Psq.Select(columns).From("comment_attachment").Where(sq.Eq{"id": ID}).ToSql()
+	if err != nil {
+		return comment.Attachment{}, err
+	}
+
+	var rows *sql.Rows
+	if tx == nil {
+		rows, err = repository.DB.QueryContext(ctx, query, values...)
+	} else {
+		rows, err = tx.QueryContext(ctx, query, values...)
+	}
+	if err != nil {
+		return comment.Attachment{}, err
+	}
+	defer repository.CloseRows(rows)
+
+	if rows.Next() {
+		return r.scanRow(rows)
+	}
+
+	return comment.Attachment{}, repository.ErrNotFound
+}
+
+func (r *repositoryImpl) Find(ctx context.Context, tx *sql.Tx, commentID int, p pagination.Pagination) ([]comment.Attachment, error) {
+	query, values, err := r.attachmentSelect(columns, commentID).OrderBy("created_at DESC", "id DESC").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if tx == nil {
+		rows, err = repository.DB.QueryContext(ctx, p.PaginateQuery(query), values...)
+	} else {
+		rows, err = tx.QueryContext(ctx, p.PaginateQuery(query), values...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	results := []comment.Attachment{}
+	for rows.Next() {
+		result, err := r.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r *repositoryImpl) Count(ctx context.Context, tx *sql.Tx, commentID int) (int, error) {
+	countQ, values, err := r.attachmentSelect("count(1)", commentID).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	if tx == nil {
+		err = repository.DB.QueryRowContext(ctx, countQ, values...).Scan(&count)
+	} else {
+		err = tx.QueryRowContext(ctx, countQ, values...).Scan(&count)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repositoryImpl) DeleteByComments(ctx context.Context, tx *sql.Tx, commentIDs []int) ([]string, error) {
+	delete, values, err := repository.Psq.Delete("comment_attachment").
+		Where(sq.Eq{"comment_id": commentIDs}).
+		Suffix("RETURNING storage_key").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, delete, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	keys := []string{}
+	for rows.Next() {
+		key := ""
+		err := rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *repositoryImpl) attachmentSelect(columns string, commentID int) sq.SelectBuilder {
+	return repository.Psq.Select(columns).From("comment_attachment").Where(sq.Eq{"comment_id": commentID})
+}
+
+func (r *repositoryImpl) scanRow(rows *sql.Rows) (comment.Attachment, error) {
+	result := comment.Attachment{}
+	err := rows.Scan(
+		&result.ID,
+		&result.CommentID,
+		&result.FileName,
+		&result.ContentType,
+		&result.Size,
+		&result.Key,
+		&result.CreatedBy,
+		&result.CreatedAt,
+	)
+
+	return result, err
+}
@@ -0,0 +1,156 @@
+package attachment_test
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/repository"
+	commentRepository "go-boilerplate/repository/comment"
+	"go-boilerplate/repository/comment/attachment"
+	"go-boilerplate/test"
+	"os"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var impl = attachment.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+// anyAttachment persists an attachment of a persisted comment and register its cleanup in the given test
+func anyAttachment(t *testing.T) comment.Attachment {
+	cmt := commentRepository.Any(t)
+	att := comment.Attachment{
+		CommentID:   cmt.ID,
+		FileName:    "contrato.pdf",
+		ContentType: "application/pdf",
+		Size:        1024,
+		Key:         "comment/" + gofakeit.UUID(),
+		CreatedBy:   gofakeit.UUID(),
+	}
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		ID, err := impl.Insert(context.Background(), tx, att)
+		if err != nil {
+			t.Errorf("error inserting comment attachment test data %s", err)
+		}
+		att.ID = ID
+	})
+
+	t.Cleanup(func() {
+		attachment.DeleteTestData(t, cmt.ID)
+	})
+	return att
+}
+
+func TestFindByID(t *testing.T) {
+	att := anyAttachment(t)
+
+	testCases := []struct {
+		name          string
+		ID            int
+		expected      comment.Attachment
+		expectedError error
+	}{
+		{
+			name:     "found attachment by id",
+			ID:       att.ID,
+			expected: att,
+		},
+		{
+			name:          "attachment not found",
+			ID:            -1,
+			expectedError: repository.ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := impl.FindByID(context.Background(), nil, tc.ID)
+			test.AssertErrorType(t, err, tc.expectedError)
+
+			if diff := cmp.Diff(result, tc.expected, cmpopts.IgnoreFields(comment.Attachment{}, "CreatedAt")); diff != "" {
+				t.Errorf("unexpected comment attachment %s", diff)
+				return
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	att := anyAttachment(t)
+	p, _ := pagination.New(0, 30)
+
+	testCases := []struct {
+		name      string
+		commentID int
+		expected  []comment.Attachment
+	}{
+		{
+			name:      "found attachments by comment",
+			commentID: att.CommentID,
+			expected: []comment.Attachment{
+				att,
+			},
+		},
+		{
+			name:      "no attachments found",
+			commentID: -1,
+			expected:  []comment.Attachment{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := impl.Find(context.Background(), nil, tc.commentID, p)
+			if err != nil {
+				t.Errorf("unexpected error finding comment attachments %s", err)
+				return
+			}
+
+			if diff := cmp.Diff(result, tc.expected, cmpopts.IgnoreFields(comment.Attachment{}, "CreatedAt")); diff != "" {
+				t.Errorf("unexpected comment attachments result %s", diff)
+				return
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	att := anyAttachment(t)
+
+	result, err := impl.Count(context.Background(), nil, att.CommentID)
+	if err != nil {
+		t.Errorf("unexpected error counting comment attachments %s", err)
+		return
+	}
+	if result != 1 {
+		t.Errorf("unexpected comment attachments count result %d", result)
+	}
+}
+
+func TestDeleteByComments(t *testing.T) {
+	att := anyAttachment(t)
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		keys, err := impl.DeleteByComments(context.Background(), tx, []int{att.CommentID})
+		if err != nil {
+			t.Errorf("unexpected error deleting comment attachments %s", err)
+			return
+		}
+		if diff := cmp.Diff(keys, []string{att.Key}); diff != "" {
+			t.Errorf("unexpected deleted attachment keys %s", diff)
+		}
+	})
+
+	_, err := impl.FindByID(context.Background(), nil, att.ID)
+	test.AssertErrorType(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,17 @@
+package attachment
+
+import (
+	"database/sql"
+	"go-boilerplate/repository"
+	"testing"
+)
+
+// DeleteTestData deletes attachments of a given comment created by some test
+func DeleteTestData(t *testing.T, commentID int) {
+	repository.Tx(t, func(tx *sql.Tx) {
+		_, err := tx.Exec("DELETE FROM comment_attachment WHERE comment_id = $1", commentID)
+		if err != nil {
+			t.Errorf("error cleaning up comment attachment test data %s", err)
+		}
+	})
+}
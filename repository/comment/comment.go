@@ -2,10 +2,15 @@
 package comment
 
 import (
+	"context"
 	sql "database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go-boilerplate/common/pagination"
 	"go-boilerplate/domain/comment"
 	"go-boilerplate/repository"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -14,6 +19,13 @@ import (
 )
 
 const (
+	searchQuery = "websearch_to_tsquery('portuguese_unaccent', ?)"
+	// escapedDescription is the description HTML-escaped, so only the marks of its search highlight are markup
+	escapedDescription = `replace(replace(replace(replace(replace(description, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+
+	// insertBatchSize of multi-row inserts, postgres allows up to 65535 parameters per statement
+	insertBatchSize = 1000
+
 	columns = `
 		id,
 		description,
@@ -24,28 +36,48 @@ const (
 		listing_id,
 		owner,
 		created_at,
-		updated_at
+		updated_at,
+		deleted_at,
+		deleted_by,
+		version,
+		parent_id,
+		depth
 	`
+
+	replyCountColumn = `(
+		SELECT count(1) FROM comment reply WHERE reply.parent_id = comment.id AND reply.deleted_at IS NULL
+	) AS reply_count`
 )
 
 var (
 	instance = &repositoryImpl{}
+
+	likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
 )
 
 // Repository to enable this repository to be mocked
 type Repository interface {
 	// Insert a comment
-	Insert(tx *sql.Tx, cmt comment.Comment) (int, error)
-	// Update a comment
-	Update(tx *sql.Tx, cmt comment.Comment) error
-	// FindByID a comment
-	FindByID(tx *sql.Tx, ID int) (comment.Comment, error)
+	Insert(ctx context.Context, tx *sql.Tx, cmt comment.Comment) (int, error)
+	// InsertBatch inserts the given comments with multi-row inserts, returning their ids in the same order
+	InsertBatch(ctx context.Context, tx *sql.Tx, cmts []comment.Comment) ([]int, error)
+	// Update a comment, checking its version when it is set
+	Update(ctx context.Context, tx *sql.Tx, cmt comment.Comment) error
+	// FindByID a comment, soft deleted ones are only found when includeDeleted is set
+	FindByID(ctx context.Context, tx *sql.Tx, ID int, includeDeleted bool) (comment.Comment, error)
 	// Find comments by a given query
-	Find(tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.Comment, error)
+	Find(ctx context.Context, tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.Comment, error)
+	// Search comments by a given query with full-text search terms, ranked by relevance
+	Search(ctx context.Context, tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.SearchResult, error)
 	// Count comments by a given query
-	Count(tx *sql.Tx, q Query) (int, error)
-	// Delete a comment
-	Delete(tx *sql.Tx, ID int) error
+	Count(ctx context.Context, tx *sql.Tx, q Query) (int, error)
+	// Delete soft deletes a comment, checking its version when it is set
+	Delete(ctx context.Context, tx *sql.Tx, ID, version int, deletedBy string) error
+	// Restore a soft deleted comment
+	Restore(ctx context.Context, tx *sql.Tx, ID int) error
+	// Purge permanently deletes comments soft deleted before the given time returning their ids,
+	// comments still having replies are kept as tombstones
+	Purge(ctx context.Context, tx *sql.Tx, before time.Time) ([]int, error)
 }
 
 type repositoryImpl struct{}
@@ -55,11 +87,73 @@ func Get() Repository {
 	return instance
 }
 
+// SortField of comments
+type SortField int
+
+const (
+	// SortNone sorts by the pagination default order, newest first
+	SortNone SortField = iota
+	// SortCreatedAt sorts by creation time
+	SortCreatedAt
+	// SortUpdatedAt sorts by last update time
+	SortUpdatedAt
+	// SortType sorts by comment type
+	SortType
+)
+
+var sortFieldValues = [...]string{
+	"",
+	"createdAt",
+	"updatedAt",
+	"type",
+}
+
+var sortFieldColumns = [...]string{
+	"",
+	"created_at",
+	"updated_at",
+	"type",
+}
+
+func (s SortField) String() string {
+	return sortFieldValues[s]
+}
+
+// SortFieldValueOf converts a sort field string into a sort field enum type
+func SortFieldValueOf(v string) (SortField, error) {
+	for i, value := range sortFieldValues {
+		if value == v {
+			return SortField(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sort field value %s", v)
+}
+
 // Query possible values to find comments
 type Query struct {
 	AccountID    string
 	AdvertiserID string
 	ListingID    string
+	// Types matches any of the given comment types
+	Types       []comment.Type
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	// Updated matches the comment updated flag when set
+	Updated *bool
+	// Text matches a case insensitive substring of the description
+	Text string
+	// ParentID matches the direct replies of the given comment
+	ParentID int
+	// Threaded matches root comments only, their replies being counted
+	Threaded bool
+	// Search matches the full-text search terms against the description, using portuguese stemming and ignoring accents
+	Search string
+	// IncludeDeleted includes soft deleted comments in results
+	IncludeDeleted bool
+	SortField      SortField
+	SortDirection  repository.OrderDirection
 }
 
 // Validate validates negotiation query
@@ -68,10 +162,20 @@ func (q Query) Validate() error {
 		validation.Field(&q.AccountID, validation.Required, is.UUID),
 		validation.Field(&q.AdvertiserID, validation.Required, is.UUID),
 		validation.Field(&q.ListingID, is.Digit),
+		validation.Field(&q.Types, validation.Each(validation.Required)),
+		validation.Field(&q.CreatedTo, validation.When(!q.CreatedFrom.IsZero(), validation.Min(q.CreatedFrom))),
+		validation.Field(&q.UpdatedTo, validation.When(!q.UpdatedFrom.IsZero(), validation.Min(q.UpdatedFrom))),
+		validation.Field(&q.Text, validation.RuneLength(0, 100)),
+		validation.Field(&q.Search, validation.RuneLength(0, 200)),
 	)
 }
 
-func (r *repositoryImpl) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
+func (r *repositoryImpl) Insert(ctx context.Context, tx *sql.Tx, cmt comment.Comment) (int, error) {
+	onrBytes, err := json.Marshal(cmt.Owner)
+	if err != nil {
+		return 0, err
+	}
+
 	insert, values, err := repository.Psq.Insert("comment").Columns(`
 		description,
 		type,
@@ -80,6 +184,9 @@ func (r *repositoryImpl) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
 		advertiser_id,
 		listing_id,
 		owner,
+		version,
+		parent_id,
+		depth,
 		created_at,
 		updated_at
 	`).Values(
@@ -89,6 +196,10 @@ func (r *repositoryImpl) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
 		cmt.AccountID,
 		cmt.AdvertiserID,
 		cmt.ListingID,
+		onrBytes,
+		1,
+		cmt.ParentID,
+		cmt.Depth,
 		time.Now(),
 		time.Now(),
 	).Suffix("RETURNING id").ToSql()
@@ -97,7 +208,7 @@ func (r *repositoryImpl) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
 	}
 
 	ID := 0
-	err = tx.QueryRow(insert, values...).Scan(&ID)
+	err = tx.QueryRowContext(ctx, insert, values...).Scan(&ID)
 	if err != nil {
 		return 0, err
 	}
@@ -105,36 +216,166 @@ func (r *repositoryImpl) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
 	return ID, nil
 }
 
-func (r *repositoryImpl) Update(tx *sql.Tx, cmt comment.Comment) error {
+func (r *repositoryImpl) InsertBatch(ctx context.Context, tx *sql.Tx, cmts []comment.Comment) ([]int, error) {
+	IDs := make([]int, 0, len(cmts))
+	for start := 0; start < len(cmts); start += insertBatchSize {
+		end := start + insertBatchSize
+		if end > len(cmts) {
+			end = len(cmts)
+		}
+
+		batchIDs, err := r.insertBatch(ctx, tx, cmts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		IDs = append(IDs, batchIDs...)
+	}
+
+	return IDs, nil
+}
+
+// insertBatch of comments in a single multi-row insert, small enough to stay below the parameters limit of a statement.
+// Their ids are reserved beforehand and inserted along with them, as the rows returned by an insert have no guaranteed order
+func (r *repositoryImpl) insertBatch(ctx context.Context, tx *sql.Tx, cmts []comment.Comment) ([]int, error) {
+	IDs, err := r.reserveIDs(ctx, tx, len(cmts))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	insertQ := repository.Psq.Insert("comment").Columns(`
+		id,
+		description,
+		type,
+		updated,
+		account_id,
+		advertiser_id,
+		listing_id,
+		owner,
+		version,
+		parent_id,
+		depth,
+		created_at,
+		updated_at
+	`)
+	for i, cmt := range cmts {
+		onrBytes, err := json.Marshal(cmt.Owner)
+		if err != nil {
+			return nil, err
+		}
+
+		insertQ = insertQ.Values(
+			IDs[i],
+			cmt.Description,
+			cmt.Type.String(),
+			false,
+			cmt.AccountID,
+			cmt.AdvertiserID,
+			cmt.ListingID,
+			onrBytes,
+			1,
+			cmt.ParentID,
+			cmt.Depth,
+			now,
+			now,
+		)
+	}
+
+	insert, values, err := insertQ.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	// the id is an identity generated always, so the reserved ones must override it
+	insert = strings.Replace(insert, " VALUES ", " OVERRIDING SYSTEM VALUE VALUES ", 1)
+
+	_, err = tx.ExecContext(ctx, insert, values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return IDs, nil
+}
+
+// reserveIDs of the given number of comments from the sequence of their identity
+func (r *repositoryImpl) reserveIDs(ctx context.Context, tx *sql.Tx, n int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT nextval(pg_get_serial_sequence('comment', 'id')) FROM generate_series(1, $1)", n)
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	IDs := make([]int, 0, n)
+	for rows.Next() {
+		ID := 0
+		err := rows.Scan(&ID)
+		if err != nil {
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, rows.Err()
+}
+
+func (r *repositoryImpl) Update(ctx context.Context, tx *sql.Tx, cmt comment.Comment) error {
 	update, values, err := repository.Psq.Update("comment").
 		Set("updated_at", time.Now()).
 		Set("updated", true).
 		Set("description", cmt.Description).
-		Where(sq.Eq{"id": cmt.ID}).
+		Set("version", sq.Expr("version + 1")).
+		Where(r.versionedWhere(cmt.ID, cmt.Version)).
 		ToSql()
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(update, values...)
+	result, err := tx.ExecContext(ctx, update, values...)
+	if err != nil {
+		return err
+	}
+
+	return r.checkVersion(ctx, tx, cmt.ID, cmt.Version, result)
+}
+
+// versionedWhere restricts a statement to the given active comment and, when set, to the given version
+func (r *repositoryImpl) versionedWhere(ID, version int) sq.Eq {
+	where := sq.Eq{"id": ID, "deleted_at": nil}
+	if version > 0 {
+		where["version"] = version
+	}
+	return where
+}
+
+// checkVersion tells whether a versioned statement affected no rows because the comment is missing or because its version moved
+func (r *repositoryImpl) checkVersion(ctx context.Context, tx *sql.Tx, ID, version int, result sql.Result) error {
+	err := repository.CheckRowsAffected(result)
+	if version == 0 || !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	_, err = r.FindByID(ctx, tx, ID, false)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return repository.ErrPreconditionFailed
 }
 
-func (r *repositoryImpl) FindByID(tx *sql.Tx, ID int) (comment.Comment, error) {
-	query, values, err := repository.Psq.Select(columns).From("comment").Where(sq.Eq{"id": ID}).ToSql()
+func (r *repositoryImpl) FindByID(ctx context.Context, tx *sql.Tx, ID int, includeDeleted bool) (comment.Comment, error) {
+	sqq := repository.Psq.Select(columns).From("comment").Where(sq.Eq{"id": ID})
+	if !includeDeleted {
+		sqq = sqq.Where(sq.Eq{"deleted_at": nil})
+	}
+	query, values, err := sqq.ToSql()
 	if err != nil {
 		return comment.Comment{}, err
 	}
 
 	var rows *sql.Rows
 	if tx == nil {
-		rows, err = repository.DB.Query(query, values...)
+		rows, err = repository.DB.QueryContext(ctx, query, values...)
 	} else {
-		rows, err = tx.Query(query, values...)
+		rows, err = tx.QueryContext(ctx, query, values...)
 	}
 	if err != nil {
 		return comment.Comment{}, err
@@ -153,17 +394,22 @@ func (r *repositoryImpl) FindByID(tx *sql.Tx, ID int) (comment.Comment, error) {
 	return comment.Comment{}, repository.ErrNotFound
 }
 
-func (r *repositoryImpl) Find(tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.Comment, error) {
-	query, values, err := r.commentSelect(columns, q).OrderBy("created_at DESC").ToSql()
+func (r *repositoryImpl) Find(ctx context.Context, tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.Comment, error) {
+	sqq := r.commentSelect(columns, q).Column(replyCountColumn)
+	if q.SortField != SortNone {
+		sqq = sqq.OrderBy(fmt.Sprintf("%s %s", sortFieldColumns[q.SortField], q.SortDirection))
+	}
+
+	query, values, err := p.PaginateSelect(sqq).ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	var rows *sql.Rows
 	if tx == nil {
-		rows, err = repository.DB.Query(p.PaginateQuery(query), values...)
+		rows, err = repository.DB.QueryContext(ctx, query, values...)
 	} else {
-		rows, err = tx.Query(p.PaginateQuery(query), values...)
+		rows, err = tx.QueryContext(ctx, query, values...)
 	}
 	if err != nil {
 		return nil, err
@@ -172,18 +418,60 @@ func (r *repositoryImpl) Find(tx *sql.Tx, q Query, p pagination.Pagination) ([]c
 
 	results := []comment.Comment{}
 	for rows.Next() {
-		result, err := r.scanRow(rows)
+		replyCount := 0
+		result, err := r.scanRow(rows, &replyCount)
 		if err != nil {
 			return nil, err
 		}
+		result.ReplyCount = replyCount
 
 		results = append(results, result)
 	}
+	if p.Backward() {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+		}
+	}
 
 	return results, nil
 }
 
-func (r *repositoryImpl) Count(tx *sql.Tx, q Query) (int, error) {
+func (r *repositoryImpl) Search(ctx context.Context, tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.SearchResult, error) {
+	query, values, err := r.commentSelect(columns, q).
+		Column(sq.Expr("ts_rank(description_tsv, "+searchQuery+") AS rank", q.Search)).
+		Column(sq.Expr("ts_headline('portuguese_unaccent', "+escapedDescription+", "+searchQuery+", 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')", q.Search)).
+		OrderBy("rank DESC", "id DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if tx == nil {
+		rows, err = repository.DB.QueryContext(ctx, p.PaginateQuery(query), values...)
+	} else {
+		rows, err = tx.QueryContext(ctx, p.PaginateQuery(query), values...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	results := []comment.SearchResult{}
+	for rows.Next() {
+		result := comment.SearchResult{}
+		result.Comment, err = r.scanRow(rows, &result.Rank, &result.Highlight)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r *repositoryImpl) Count(ctx context.Context, tx *sql.Tx, q Query) (int, error) {
 	countQ, values, err := r.commentSelect("count(1)", q).ToSql()
 	if err != nil {
 		return 0, err
@@ -191,9 +479,9 @@ func (r *repositoryImpl) Count(tx *sql.Tx, q Query) (int, error) {
 
 	count := 0
 	if tx == nil {
-		err = repository.DB.QueryRow(countQ, values...).Scan(&count)
+		err = repository.DB.QueryRowContext(ctx, countQ, values...).Scan(&count)
 	} else {
-		err = tx.QueryRow(countQ, values...).Scan(&count)
+		err = tx.QueryRowContext(ctx, countQ, values...).Scan(&count)
 	}
 	if err != nil {
 		return 0, err
@@ -213,15 +501,62 @@ func (r *repositoryImpl) commentSelect(columns string, q Query) sq.SelectBuilder
 	if q.AdvertiserID != "" {
 		sqq = sqq.Where(sq.Eq{"advertiser_id": q.AdvertiserID})
 	}
+	if len(q.Types) > 0 {
+		types := make([]string, len(q.Types))
+		for i, tp := range q.Types {
+			types[i] = tp.String()
+		}
+		sqq = sqq.Where(sq.Eq{"type": types})
+	}
+	if !q.CreatedFrom.IsZero() {
+		sqq = sqq.Where(sq.GtOrEq{"created_at": q.CreatedFrom})
+	}
+	if !q.CreatedTo.IsZero() {
+		sqq = sqq.Where(sq.LtOrEq{"created_at": q.CreatedTo})
+	}
+	if !q.UpdatedFrom.IsZero() {
+		sqq = sqq.Where(sq.GtOrEq{"updated_at": q.UpdatedFrom})
+	}
+	if !q.UpdatedTo.IsZero() {
+		sqq = sqq.Where(sq.LtOrEq{"updated_at": q.UpdatedTo})
+	}
+	if q.Updated != nil {
+		sqq = sqq.Where(sq.Eq{"updated": *q.Updated})
+	}
+	if q.Search != "" {
+		sqq = sqq.Where(sq.Expr("description_tsv @@ "+searchQuery, q.Search))
+	}
+	if q.Text != "" {
+		sqq = sqq.Where(sq.ILike{"description": "%" + likeEscaper.Replace(q.Text) + "%"})
+	}
+	if q.ParentID != 0 {
+		sqq = sqq.Where(sq.Eq{"parent_id": q.ParentID})
+	}
+	if q.Threaded {
+		sqq = sqq.Where(sq.Eq{"parent_id": nil})
+	}
+	if !q.IncludeDeleted && (q.Threaded || q.ParentID != 0) {
+		// soft deleted comments with active replies are kept in threads as tombstones
+		sqq = sqq.Where(sq.Or{
+			sq.Eq{"deleted_at": nil},
+			sq.Expr("EXISTS (SELECT 1 FROM comment reply WHERE reply.parent_id = comment.id AND reply.deleted_at IS NULL)"),
+		})
+	} else if !q.IncludeDeleted {
+		sqq = sqq.Where(sq.Eq{"deleted_at": nil})
+	}
 
 	return sqq
 }
 
-func (r *repositoryImpl) scanRow(rows *sql.Rows) (comment.Comment, error) {
+// scanRow scans a comment row, scanning any extra selected columns into the given destinations
+func (r *repositoryImpl) scanRow(rows *sql.Rows, extra ...interface{}) (comment.Comment, error) {
 	result := comment.Comment{}
 	tpValue := ""
 	onrBytes := []byte{}
-	err := rows.Scan(
+	deletedAt := sql.NullTime{}
+	deletedBy := sql.NullString{}
+	parentID := sql.NullInt64{}
+	dest := []interface{}{
 		&result.ID,
 		&result.Description,
 		&tpValue,
@@ -232,7 +567,13 @@ func (r *repositoryImpl) scanRow(rows *sql.Rows) (comment.Comment, error) {
 		&onrBytes,
 		&result.CreatedAt,
 		&result.UpdatedAt,
-	)
+		&deletedAt,
+		&deletedBy,
+		&result.Version,
+		&parentID,
+		&result.Depth,
+	}
+	err := rows.Scan(append(dest, extra...)...)
 	if err != nil {
 		return result, err
 	}
@@ -243,19 +584,88 @@ func (r *repositoryImpl) scanRow(rows *sql.Rows) (comment.Comment, error) {
 	}
 	result.Type = tp
 
+	if deletedAt.Valid {
+		result.DeletedAt = &deletedAt.Time
+		result.DeletedBy = deletedBy.String
+	}
+	if parentID.Valid {
+		ID := int(parentID.Int64)
+		result.ParentID = &ID
+	}
+
+	err = json.Unmarshal(onrBytes, &result.Owner)
+	if err != nil {
+		return result, err
+	}
+
 	return result, nil
 }
 
-func (r *repositoryImpl) Delete(tx *sql.Tx, ID int) error {
-	delete, values, err := repository.Psq.Delete("comment").Where(sq.Eq{"id": ID}).ToSql()
+func (r *repositoryImpl) Delete(ctx context.Context, tx *sql.Tx, ID, version int, deletedBy string) error {
+	update, values, err := repository.Psq.Update("comment").
+		Set("deleted_at", time.Now()).
+		Set("deleted_by", deletedBy).
+		Set("version", sq.Expr("version + 1")).
+		Where(r.versionedWhere(ID, version)).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, update, values...)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(delete, values...)
+	return r.checkVersion(ctx, tx, ID, version, result)
+}
+
+func (r *repositoryImpl) Restore(ctx context.Context, tx *sql.Tx, ID int) error {
+	update, values, err := repository.Psq.Update("comment").
+		Set("updated_at", time.Now()).
+		Set("deleted_at", nil).
+		Set("deleted_by", nil).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": ID}).
+		Where(sq.NotEq{"deleted_at": nil}).
+		ToSql()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	result, err := tx.ExecContext(ctx, update, values...)
+	if err != nil {
+		return err
+	}
+
+	return repository.CheckRowsAffected(result)
+}
+
+func (r *repositoryImpl) Purge(ctx context.Context, tx *sql.Tx, before time.Time) ([]int, error) {
+	delete, values, err := repository.Psq.Delete("comment").
+		Where(sq.Lt{"deleted_at": before}).
+		Where("NOT EXISTS (SELECT 1 FROM comment reply WHERE reply.parent_id = comment.id)").
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, delete, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	IDs := []int{}
+	for rows.Next() {
+		ID := 0
+		err := rows.Scan(&ID)
+		if err != nil {
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, rows.Err()
 }
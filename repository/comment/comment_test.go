@@ -1,6 +1,7 @@
 package comment_test
 
 import (
+	"context"
 	"database/sql"
 	"go-boilerplate/common/pagination"
 	"go-boilerplate/domain/comment"
@@ -8,8 +9,12 @@ import (
 	commentRepository "go-boilerplate/repository/comment"
 	"go-boilerplate/test"
 	"go-boilerplate/test/fixtures"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v5"
 	"github.com/google/go-cmp/cmp"
@@ -78,6 +83,44 @@ func TestValidateQuery(t *testing.T) {
 			},
 			expectedErr: "ListingID: must contain digits only.",
 		},
+		{
+			name: "invalid type",
+			query: commentRepository.Query{
+				AccountID:    gofakeit.UUID(),
+				AdvertiserID: gofakeit.UUID(),
+				Types:        []comment.Type{comment.Lead, comment.TypeNone},
+			},
+			expectedErr: "Types: (1: cannot be blank.).",
+		},
+		{
+			name: "created range ending before it starts",
+			query: commentRepository.Query{
+				AccountID:    gofakeit.UUID(),
+				AdvertiserID: gofakeit.UUID(),
+				CreatedFrom:  time.Date(2021, 1, 6, 0, 0, 0, 0, time.UTC),
+				CreatedTo:    time.Date(2021, 1, 5, 0, 0, 0, 0, time.UTC),
+			},
+			expectedErr: "CreatedTo: must be no less than 2021-01-06 00:00:00 +0000 UTC.",
+		},
+		{
+			name: "updated range ending before it starts",
+			query: commentRepository.Query{
+				AccountID:    gofakeit.UUID(),
+				AdvertiserID: gofakeit.UUID(),
+				UpdatedFrom:  time.Date(2021, 1, 6, 0, 0, 0, 0, time.UTC),
+				UpdatedTo:    time.Date(2021, 1, 5, 0, 0, 0, 0, time.UTC),
+			},
+			expectedErr: "UpdatedTo: must be no less than 2021-01-06 00:00:00 +0000 UTC.",
+		},
+		{
+			name: "too long text",
+			query: commentRepository.Query{
+				AccountID:    gofakeit.UUID(),
+				AdvertiserID: gofakeit.UUID(),
+				Text:         gofakeit.LetterN(101),
+			},
+			expectedErr: "Text: the length must be no more than 100.",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -105,7 +148,7 @@ func TestInsert(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository.Tx(t, func(tx *sql.Tx) {
-				ID, err := impl.Insert(tx, tc.comment)
+				ID, err := impl.Insert(context.Background(), tx, tc.comment)
 				if err != nil {
 					t.Errorf("unexpected error inserting comment %s", err)
 					return
@@ -120,11 +163,48 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsertBatch(t *testing.T) {
+	cmts := []comment.Comment{fixtures.AnyComment(), fixtures.AnyComment(), fixtures.AnyComment()}
+	IDs := []int{}
+	t.Cleanup(func() {
+		for _, ID := range IDs {
+			commentRepository.DeleteTestData(t, ID)
+		}
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		var err error
+		IDs, err = impl.InsertBatch(context.Background(), tx, cmts)
+		if err != nil {
+			t.Errorf("unexpected error inserting comments %s", err)
+			return
+		}
+		if len(IDs) != len(cmts) {
+			t.Errorf("unexpected inserted comment ids %v", IDs)
+			return
+		}
+
+		for i, ID := range IDs {
+			result, err := impl.FindByID(context.Background(), tx, ID, false)
+			if err != nil {
+				t.Errorf("unexpected error finding inserted comment %s", err)
+				return
+			}
+			if result.Description != cmts[i].Description {
+				t.Errorf("unexpected comment %d description %s", ID, result.Description)
+			}
+		}
+	})
+}
+
 func TestUpdate(t *testing.T) {
 	cmt := commentRepository.Any(t)
 	cmt.Updated = true
 	cmt.Description = gofakeit.Phrase()
 
+	expected := cmt
+	expected.Version = cmt.Version + 1
+
 	testCases := []struct {
 		name     string
 		comment  comment.Comment
@@ -133,13 +213,13 @@ func TestUpdate(t *testing.T) {
 		{
 			name:     "comment updated successfully",
 			comment:  cmt,
-			expected: cmt,
+			expected: expected,
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository.Tx(t, func(tx *sql.Tx) {
-				err := impl.Update(tx, tc.expected)
+				err := impl.Update(context.Background(), tx, tc.comment)
 				if err != nil {
 					t.Errorf("unexpected error updating comment %s", err)
 					return
@@ -155,6 +235,26 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateNotFound(t *testing.T) {
+	cmt := fixtures.AnyComment()
+	cmt.ID = -1
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Update(context.Background(), tx, cmt)
+		test.AssertErrorType(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestUpdateVersionMismatch(t *testing.T) {
+	cmt := commentRepository.Any(t)
+	cmt.Version++
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Update(context.Background(), tx, cmt)
+		test.AssertErrorType(t, err, repository.ErrPreconditionFailed)
+	})
+}
+
 func TestFindByID(t *testing.T) {
 	cmt := commentRepository.Any(t)
 	testCases := []struct {
@@ -172,7 +272,7 @@ func TestFindByID(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository.Tx(t, func(tx *sql.Tx) {
-				l, err := impl.FindByID(tx, tc.ID)
+				l, err := impl.FindByID(context.Background(), tx, tc.ID, false)
 				if err != nil {
 					t.Errorf("unexpected error finding comment %s", err)
 					return
@@ -190,6 +290,11 @@ func TestFind(t *testing.T) {
 	cmt := commentRepository.Any(t)
 	p, _ := pagination.New(0, 30)
 
+	stored := commentRepository.Comment(t, cmt.ID)
+	first, _ := pagination.New(0, 1)
+	page := first.GetKeysetResponse(1, nil, []pagination.Key{{CreatedAt: stored.CreatedAt, ID: stored.ID}})
+	after, _ := pagination.FromRequest(httptest.NewRequest(http.MethodGet, "/v1/comment?size=1&cursor="+page.NextCursor, nil))
+
 	testCases := []struct {
 		name     string
 		q        commentRepository.Query
@@ -208,11 +313,58 @@ func TestFind(t *testing.T) {
 				cmt,
 			},
 		},
+		{
+			name: "found comments by type and text",
+			q: commentRepository.Query{
+				AccountID:    cmt.AccountID,
+				AdvertiserID: cmt.AdvertiserID,
+				Types:        []comment.Type{comment.Credit, cmt.Type},
+				Text:         strings.ToUpper(cmt.Description[1 : len(cmt.Description)-1]),
+				Updated:      &cmt.Updated,
+				CreatedFrom:  stored.CreatedAt.Add(-time.Minute),
+				CreatedTo:    stored.CreatedAt.Add(time.Minute),
+				SortField:    commentRepository.SortUpdatedAt,
+			},
+			p: p,
+			expected: []comment.Comment{
+				cmt,
+			},
+		},
+		{
+			name: "no comments by created range",
+			q: commentRepository.Query{
+				AccountID:    cmt.AccountID,
+				AdvertiserID: cmt.AdvertiserID,
+				CreatedTo:    stored.CreatedAt.Add(-time.Minute),
+			},
+			p:        p,
+			expected: []comment.Comment{},
+		},
+		{
+			name: "no comments by text",
+			q: commentRepository.Query{
+				AccountID:    cmt.AccountID,
+				AdvertiserID: cmt.AdvertiserID,
+				Text:         "%",
+			},
+			p:        p,
+			expected: []comment.Comment{},
+		},
+		{
+			name: "no comments after the last one",
+			q: commentRepository.Query{
+				AccountID:    cmt.AccountID,
+				AdvertiserID: cmt.AdvertiserID,
+				ListingID:    cmt.ListingID,
+			},
+			p:        after,
+			expected: []comment.Comment{},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository.Tx(t, func(tx *sql.Tx) {
-				result, err := impl.Find(tx, tc.q, tc.p)
+				result, err := impl.Find(context.Background(), tx, tc.q, tc.p)
 				if err != nil {
 					t.Errorf("unexpected error finding comments %s", err)
 					return
@@ -227,6 +379,136 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestFindThreads(t *testing.T) {
+	parent := commentRepository.Any(t)
+	reply := commentRepository.AnyReply(t, parent)
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Delete(context.Background(), tx, parent.ID, 0, gofakeit.UUID())
+		if err != nil {
+			t.Errorf("unexpected error deleting comment %s", err)
+		}
+	})
+	p, _ := pagination.New(0, 30)
+
+	testCases := []struct {
+		name     string
+		q        commentRepository.Query
+		expected []int
+	}{
+		{
+			name: "deleted root kept in thread because of its reply",
+			q: commentRepository.Query{
+				AccountID:    parent.AccountID,
+				AdvertiserID: parent.AdvertiserID,
+				Threaded:     true,
+			},
+			expected: []int{parent.ID},
+		},
+		{
+			name: "replies of a deleted comment",
+			q: commentRepository.Query{
+				AccountID:    parent.AccountID,
+				AdvertiserID: parent.AdvertiserID,
+				ParentID:     parent.ID,
+			},
+			expected: []int{reply.ID},
+		},
+		{
+			name: "deleted root out of flat listing",
+			q: commentRepository.Query{
+				AccountID:    parent.AccountID,
+				AdvertiserID: parent.AdvertiserID,
+			},
+			expected: []int{reply.ID},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := impl.Find(context.Background(), nil, tc.q, p)
+			if err != nil {
+				t.Errorf("unexpected error finding comments %s", err)
+				return
+			}
+
+			IDs := []int{}
+			for _, result := range results {
+				IDs = append(IDs, result.ID)
+			}
+			if diff := cmp.Diff(IDs, tc.expected); diff != "" {
+				t.Errorf("unexpected comments result %s", diff)
+				return
+			}
+			if tc.q.Threaded && results[0].ReplyCount != 1 {
+				t.Errorf("unexpected reply count %d", results[0].ReplyCount)
+				return
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	cmt := fixtures.AnyComment()
+	cmt.Description = "O <b>inquilino</b> ainda não apresentou o fiador"
+	repository.Tx(t, func(tx *sql.Tx) {
+		ID, err := impl.Insert(context.Background(), tx, cmt)
+		if err != nil {
+			t.Errorf("error inserting comment test data %s", err)
+		}
+		cmt.ID = ID
+		cmt.Version = 1
+	})
+	t.Cleanup(func() {
+		commentRepository.DeleteTestData(t, cmt.ID)
+	})
+	p, _ := pagination.New(0, 30)
+
+	testCases := []struct {
+		name              string
+		search            string
+		expected          []comment.Comment
+		expectedHighlight string
+	}{
+		{
+			name:              "found comments by stemmed and unaccented terms",
+			search:            "fiadores nao",
+			expected:          []comment.Comment{cmt},
+			expectedHighlight: "O &lt;b&gt;inquilino&lt;/b&gt; ainda <mark>não</mark> apresentou o <mark>fiador</mark>",
+		},
+		{
+			name:     "no comments excluding a term",
+			search:   "fiador -inquilino",
+			expected: []comment.Comment{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := commentRepository.Query{
+				AccountID:    cmt.AccountID,
+				AdvertiserID: cmt.AdvertiserID,
+				Search:       tc.search,
+			}
+			results, err := impl.Search(context.Background(), nil, q, p)
+			if err != nil {
+				t.Errorf("unexpected error searching comments %s", err)
+				return
+			}
+
+			comments := []comment.Comment{}
+			for _, result := range results {
+				comments = append(comments, result.Comment)
+				if result.Highlight != tc.expectedHighlight || result.Rank <= 0 {
+					t.Errorf("unexpected search result %+v", result)
+					return
+				}
+			}
+			if diff := cmp.Diff(comments, tc.expected, cmpopts.IgnoreFields(comment.Comment{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Errorf("unexpected comments result %s", diff)
+				return
+			}
+		})
+	}
+}
+
 func TestCount(t *testing.T) {
 	cmt := commentRepository.Any(t)
 
@@ -248,7 +530,7 @@ func TestCount(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository.Tx(t, func(tx *sql.Tx) {
-				result, err := impl.Count(tx, tc.q)
+				result, err := impl.Count(context.Background(), tx, tc.q)
 				if err != nil {
 					t.Errorf("unexpected error counting comments %s", err)
 					return
@@ -265,25 +547,135 @@ func TestCount(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	cmt := commentRepository.Any(t)
+	deletedBy := gofakeit.UUID()
 
 	testCases := []struct {
 		name string
 		ID   int
 	}{
 		{
-			name: "comment deleted successfully",
+			name: "comment soft deleted successfully",
 			ID:   cmt.ID,
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			repository.Tx(t, func(tx *sql.Tx) {
-				err := impl.Delete(tx, tc.ID)
+				err := impl.Delete(context.Background(), tx, tc.ID, 0, deletedBy)
 				if err != nil {
 					t.Errorf("unexpected error deleting comment %s", err)
 					return
 				}
 			})
+
+			_, err := impl.FindByID(context.Background(), nil, tc.ID, false)
+			test.AssertErrorType(t, err, repository.ErrNotFound)
+
+			result := commentRepository.Comment(t, tc.ID)
+			if !result.Deleted() || result.DeletedBy != deletedBy {
+				t.Errorf("unexpected deleted comment %+v", result)
+				return
+			}
+		})
+	}
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Delete(context.Background(), tx, -1, 0, gofakeit.UUID())
+		test.AssertErrorType(t, err, repository.ErrNotFound)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	cmt := commentRepository.Any(t)
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Delete(context.Background(), tx, cmt.ID, 0, gofakeit.UUID())
+		if err != nil {
+			t.Errorf("unexpected error deleting comment %s", err)
+		}
+	})
+
+	testCases := []struct {
+		name string
+		ID   int
+	}{
+		{
+			name: "comment restored successfully",
+			ID:   cmt.ID,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository.Tx(t, func(tx *sql.Tx) {
+				err := impl.Restore(context.Background(), tx, tc.ID)
+				if err != nil {
+					t.Errorf("unexpected error restoring comment %s", err)
+					return
+				}
+			})
+
+			result, err := impl.FindByID(context.Background(), nil, tc.ID, false)
+			if err != nil {
+				t.Errorf("unexpected error finding restored comment %s", err)
+				return
+			}
+			if result.Deleted() || result.DeletedBy != "" {
+				t.Errorf("unexpected restored comment %+v", result)
+				return
+			}
 		})
 	}
 }
+
+func TestPurge(t *testing.T) {
+	deleted := commentRepository.Any(t)
+	kept := commentRepository.Any(t)
+	tombstone := commentRepository.Any(t)
+	commentRepository.AnyReply(t, tombstone)
+	repository.Tx(t, func(tx *sql.Tx) {
+		for _, ID := range []int{deleted.ID, tombstone.ID} {
+			err := impl.Delete(context.Background(), tx, ID, 0, gofakeit.UUID())
+			if err != nil {
+				t.Errorf("unexpected error deleting comment %s", err)
+			}
+		}
+	})
+
+	testCases := []struct {
+		name     string
+		before   time.Time
+		expected []int
+	}{
+		{
+			name:     "nothing purged inside retention window",
+			before:   time.Now().Add(-time.Hour),
+			expected: []int{},
+		},
+		{
+			name:     "soft deleted comment without replies purged",
+			before:   time.Now().Add(time.Hour),
+			expected: []int{deleted.ID},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository.Tx(t, func(tx *sql.Tx) {
+				result, err := impl.Purge(context.Background(), tx, tc.before)
+				if err != nil {
+					t.Errorf("unexpected error purging comments %s", err)
+					return
+				}
+				if diff := cmp.Diff(result, tc.expected); diff != "" {
+					t.Errorf("unexpected purged comments %s", diff)
+					return
+				}
+			})
+		})
+	}
+
+	_, err := impl.FindByID(context.Background(), nil, kept.ID, false)
+	if err != nil {
+		t.Errorf("unexpected error finding kept comment %s", err)
+	}
+}
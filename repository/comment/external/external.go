@@ -0,0 +1,88 @@
+// Package external holds data access logic of comments imported from other CRM systems,
+// linking their external IDs to the imported comments so each one is imported only once
+package external
+
+import (
+	"context"
+	sql "database/sql"
+	"errors"
+	"go-boilerplate/repository"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var (
+	instance = &repositoryImpl{}
+)
+
+// Repository to enable this repository to be mocked
+type Repository interface {
+	// Insert the link of an external ID of the given source to an imported comment
+	Insert(ctx context.Context, tx *sql.Tx, source, externalID string, commentID int) error
+	// FindCommentID imported from the given source with the given external ID
+	FindCommentID(ctx context.Context, tx *sql.Tx, source, externalID string) (int, error)
+	// DeleteByComments deletes the links of the given comments
+	DeleteByComments(ctx context.Context, tx *sql.Tx, commentIDs []int) error
+}
+
+type repositoryImpl struct{}
+
+// Get this repository instance
+func Get() Repository {
+	return instance
+}
+
+func (r *repositoryImpl) Insert(ctx context.Context, tx *sql.Tx, source, externalID string, commentID int) error {
+	insert, values, err := repository.Psq.Insert("comment_import").Columns(`
+		source,
+		external_id,
+		comment_id,
+		created_at
+	`).Values(
+		source,
+		externalID,
+		commentID,
+		time.Now(),
+	).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, insert, values...)
+	return err
+}
+
+func (r *repositoryImpl) FindCommentID(ctx context.Context, tx *sql.Tx, source, externalID string) (int, error) {
+	query, values, err := repository.Psq.Select("comment_id").From("comment_import").
+		Where(sq.Eq{"source": source, "external_id": externalID}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	commentID := 0
+	if tx == nil {
+		err = repository.DB.QueryRowContext(ctx, query, values...).Scan(&commentID)
+	} else {
+		err = tx.QueryRowContext(ctx, query, values...).Scan(&commentID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return commentID, nil
+}
+
+func (r *repositoryImpl) DeleteByComments(ctx context.Context, tx *sql.Tx, commentIDs []int) error {
+	delete, values, err := repository.Psq.Delete("comment_import").Where(sq.Eq{"comment_id": commentIDs}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, delete, values...)
+	return err
+}
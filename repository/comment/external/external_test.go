@@ -0,0 +1,115 @@
+package external_test
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/repository"
+	commentRepository "go-boilerplate/repository/comment"
+	"go-boilerplate/repository/comment/external"
+	"go-boilerplate/test"
+	"os"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+)
+
+var impl = external.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestFindCommentID(t *testing.T) {
+	cmt := commentRepository.Any(t)
+	externalID := gofakeit.UUID()
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Insert(context.Background(), tx, "crm", externalID, cmt.ID)
+		if err != nil {
+			t.Errorf("error inserting comment import test data %s", err)
+		}
+	})
+	t.Cleanup(func() {
+		external.DeleteTestData(t, cmt.ID)
+	})
+
+	testCases := []struct {
+		name          string
+		source        string
+		externalID    string
+		expected      int
+		expectedError error
+	}{
+		{
+			name:       "found imported comment",
+			source:     "crm",
+			externalID: externalID,
+			expected:   cmt.ID,
+		},
+		{
+			name:          "external id of another source",
+			source:        "another-crm",
+			externalID:    externalID,
+			expectedError: repository.ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := impl.FindCommentID(context.Background(), nil, tc.source, tc.externalID)
+			test.AssertErrorType(t, err, tc.expectedError)
+
+			if result != tc.expected {
+				t.Errorf("unexpected imported comment id %d", result)
+			}
+		})
+	}
+}
+
+func TestInsertDuplicate(t *testing.T) {
+	cmt := commentRepository.Any(t)
+	externalID := gofakeit.UUID()
+	t.Cleanup(func() {
+		external.DeleteTestData(t, cmt.ID)
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Insert(context.Background(), tx, "crm", externalID, cmt.ID)
+		if err != nil {
+			t.Errorf("error inserting comment import test data %s", err)
+		}
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Insert(context.Background(), tx, "crm", externalID, cmt.ID)
+		if !repository.IsUniqueConstraintViolation(err) {
+			t.Errorf("unexpected error inserting duplicate comment import %v", err)
+		}
+	})
+}
+
+func TestDeleteByComments(t *testing.T) {
+	cmt := commentRepository.Any(t)
+	externalID := gofakeit.UUID()
+	t.Cleanup(func() {
+		external.DeleteTestData(t, cmt.ID)
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.Insert(context.Background(), tx, "crm", externalID, cmt.ID)
+		if err != nil {
+			t.Errorf("error inserting comment import test data %s", err)
+		}
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.DeleteByComments(context.Background(), tx, []int{cmt.ID})
+		test.AssertError(t, err, "")
+	})
+
+	_, err := impl.FindCommentID(context.Background(), nil, "crm", externalID)
+	test.AssertErrorType(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,17 @@
+package external
+
+import (
+	"database/sql"
+	"go-boilerplate/repository"
+	"testing"
+)
+
+// DeleteTestData deletes links of a given imported comment created by some test
+func DeleteTestData(t *testing.T, commentID int) {
+	repository.Tx(t, func(tx *sql.Tx) {
+		_, err := tx.Exec("DELETE FROM comment_import WHERE comment_id = $1", commentID)
+		if err != nil {
+			t.Errorf("error cleaning up comment import test data %s", err)
+		}
+	})
+}
@@ -3,11 +3,16 @@
 package comment
 
 import (
+	context "context"
 	pagination "go-boilerplate/common/pagination"
-	"go-boilerplate/domain/comment"
-	sql "database/sql"
+
+	comment "go-boilerplate/domain/comment"
 
 	mock "github.com/stretchr/testify/mock"
+
+	sql "database/sql"
+
+	time "time"
 )
 
 // MockRepository is an autogenerated mock type for the Repository type
@@ -15,20 +20,20 @@ type MockRepository struct {
 	mock.Mock
 }
 
-// Count provides a mock function with given fields: tx, q
-func (_m *MockRepository) Count(tx *sql.Tx, q Query) (int, error) {
-	ret := _m.Called(tx, q)
+// Count provides a mock function with given fields: ctx, tx, q
+func (_m *MockRepository) Count(ctx context.Context, tx *sql.Tx, q Query) (int, error) {
+	ret := _m.Called(ctx, tx, q)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(*sql.Tx, Query) int); ok {
-		r0 = rf(tx, q)
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, Query) int); ok {
+		r0 = rf(ctx, tx, q)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*sql.Tx, Query) error); ok {
-		r1 = rf(tx, q)
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, Query) error); ok {
+		r1 = rf(ctx, tx, q)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -36,13 +41,13 @@ func (_m *MockRepository) Count(tx *sql.Tx, q Query) (int, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: tx, ID
-func (_m *MockRepository) Delete(tx *sql.Tx, ID int) error {
-	ret := _m.Called(tx, ID)
+// Delete provides a mock function with given fields: ctx, tx, ID, version, deletedBy
+func (_m *MockRepository) Delete(ctx context.Context, tx *sql.Tx, ID int, version int, deletedBy string) error {
+	ret := _m.Called(ctx, tx, ID, version, deletedBy)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*sql.Tx, int) error); ok {
-		r0 = rf(tx, ID)
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, int, int, string) error); ok {
+		r0 = rf(ctx, tx, ID, version, deletedBy)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -50,13 +55,13 @@ func (_m *MockRepository) Delete(tx *sql.Tx, ID int) error {
 	return r0
 }
 
-// Find provides a mock function with given fields: tx, q, p
-func (_m *MockRepository) Find(tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.Comment, error) {
-	ret := _m.Called(tx, q, p)
+// Find provides a mock function with given fields: ctx, tx, q, p
+func (_m *MockRepository) Find(ctx context.Context, tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.Comment, error) {
+	ret := _m.Called(ctx, tx, q, p)
 
 	var r0 []comment.Comment
-	if rf, ok := ret.Get(0).(func(*sql.Tx, Query, pagination.Pagination) []comment.Comment); ok {
-		r0 = rf(tx, q, p)
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, Query, pagination.Pagination) []comment.Comment); ok {
+		r0 = rf(ctx, tx, q, p)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]comment.Comment)
@@ -64,8 +69,8 @@ func (_m *MockRepository) Find(tx *sql.Tx, q Query, p pagination.Pagination) ([]
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*sql.Tx, Query, pagination.Pagination) error); ok {
-		r1 = rf(tx, q, p)
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, Query, pagination.Pagination) error); ok {
+		r1 = rf(ctx, tx, q, p)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -73,20 +78,20 @@ func (_m *MockRepository) Find(tx *sql.Tx, q Query, p pagination.Pagination) ([]
 	return r0, r1
 }
 
-// FindByID provides a mock function with given fields: tx, ID
-func (_m *MockRepository) FindByID(tx *sql.Tx, ID int) (comment.Comment, error) {
-	ret := _m.Called(tx, ID)
+// FindByID provides a mock function with given fields: ctx, tx, ID, includeDeleted
+func (_m *MockRepository) FindByID(ctx context.Context, tx *sql.Tx, ID int, includeDeleted bool) (comment.Comment, error) {
+	ret := _m.Called(ctx, tx, ID, includeDeleted)
 
 	var r0 comment.Comment
-	if rf, ok := ret.Get(0).(func(*sql.Tx, int) comment.Comment); ok {
-		r0 = rf(tx, ID)
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, int, bool) comment.Comment); ok {
+		r0 = rf(ctx, tx, ID, includeDeleted)
 	} else {
 		r0 = ret.Get(0).(comment.Comment)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*sql.Tx, int) error); ok {
-		r1 = rf(tx, ID)
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, int, bool) error); ok {
+		r1 = rf(ctx, tx, ID, includeDeleted)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,20 +99,103 @@ func (_m *MockRepository) FindByID(tx *sql.Tx, ID int) (comment.Comment, error)
 	return r0, r1
 }
 
-// Insert provides a mock function with given fields: tx, cmt
-func (_m *MockRepository) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
-	ret := _m.Called(tx, cmt)
+// Insert provides a mock function with given fields: ctx, tx, cmt
+func (_m *MockRepository) Insert(ctx context.Context, tx *sql.Tx, cmt comment.Comment) (int, error) {
+	ret := _m.Called(ctx, tx, cmt)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(*sql.Tx, comment.Comment) int); ok {
-		r0 = rf(tx, cmt)
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, comment.Comment) int); ok {
+		r0 = rf(ctx, tx, cmt)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*sql.Tx, comment.Comment) error); ok {
-		r1 = rf(tx, cmt)
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, comment.Comment) error); ok {
+		r1 = rf(ctx, tx, cmt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertBatch provides a mock function with given fields: ctx, tx, cmts
+func (_m *MockRepository) InsertBatch(ctx context.Context, tx *sql.Tx, cmts []comment.Comment) ([]int, error) {
+	ret := _m.Called(ctx, tx, cmts)
+
+	var r0 []int
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, []comment.Comment) []int); ok {
+		r0 = rf(ctx, tx, cmts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, []comment.Comment) error); ok {
+		r1 = rf(ctx, tx, cmts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, tx, before
+func (_m *MockRepository) Purge(ctx context.Context, tx *sql.Tx, before time.Time) ([]int, error) {
+	ret := _m.Called(ctx, tx, before)
+
+	var r0 []int
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, time.Time) []int); ok {
+		r0 = rf(ctx, tx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, time.Time) error); ok {
+		r1 = rf(ctx, tx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, tx, ID
+func (_m *MockRepository) Restore(ctx context.Context, tx *sql.Tx, ID int) error {
+	ret := _m.Called(ctx, tx, ID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, int) error); ok {
+		r0 = rf(ctx, tx, ID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: ctx, tx, q, p
+func (_m *MockRepository) Search(ctx context.Context, tx *sql.Tx, q Query, p pagination.Pagination) ([]comment.SearchResult, error) {
+	ret := _m.Called(ctx, tx, q, p)
+
+	var r0 []comment.SearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, Query, pagination.Pagination) []comment.SearchResult); ok {
+		r0 = rf(ctx, tx, q, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]comment.SearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *sql.Tx, Query, pagination.Pagination) error); ok {
+		r1 = rf(ctx, tx, q, p)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -115,13 +203,13 @@ func (_m *MockRepository) Insert(tx *sql.Tx, cmt comment.Comment) (int, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: tx, cmt
-func (_m *MockRepository) Update(tx *sql.Tx, cmt comment.Comment) error {
-	ret := _m.Called(tx, cmt)
+// Update provides a mock function with given fields: ctx, tx, cmt
+func (_m *MockRepository) Update(ctx context.Context, tx *sql.Tx, cmt comment.Comment) error {
+	ret := _m.Called(ctx, tx, cmt)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*sql.Tx, comment.Comment) error); ok {
-		r0 = rf(tx, cmt)
+	if rf, ok := ret.Get(0).(func(context.Context, *sql.Tx, comment.Comment) error); ok {
+		r0 = rf(ctx, tx, cmt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,176 @@
+// Package revision holds data access logic of comment revisions
+package revision
+
+import (
+	"context"
+	sql "database/sql"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/repository"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	columns = `
+		id,
+		comment_id,
+		action,
+		type,
+		description,
+		changed_by,
+		created_at
+	`
+)
+
+var (
+	instance = &repositoryImpl{}
+)
+
+// Repository to enable this repository to be mocked
+type Repository interface {
+	// Insert a comment revision
+	Insert(ctx context.Context, tx *sql.Tx, rev comment.Revision) (int, error)
+	// Find revisions of a given comment
+	Find(ctx context.Context, tx *sql.Tx, commentID int, p pagination.Pagination) ([]comment.Revision, error)
+	// Count revisions of a given comment
+	Count(ctx context.Context, tx *sql.Tx, commentID int) (int, error)
+	// DeleteByComments deletes all revisions of the given comments
+	DeleteByComments(ctx context.Context, tx *sql.Tx, commentIDs []int) error
+}
+
+type repositoryImpl struct{}
+
+// Get this repository instance
+func Get() Repository {
+	return instance
+}
+
+func (r *repositoryImpl) Insert(ctx context.Context, tx *sql.Tx, rev comment.Revision) (int, error) {
+	insert, values, err := repository.Psq.Insert("comment_revision").Columns(`
+		comment_id,
+		action,
+		type,
+		description,
+		changed_by,
+		created_at
+	`).Values(
+		rev.CommentID,
+		rev.Action.String(),
+		rev.Type.String(),
+		rev.Description,
+		rev.ChangedBy,
+		time.Now(),
+	).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	ID := 0
+	err = tx.QueryRowContext(ctx, insert, values...).Scan(&ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, nil
+}
+
+func (r *repositoryImpl) Find(ctx context.Context, tx *sql.Tx, commentID int, p pagination.Pagination) ([]comment.Revision, error) {
+	query, values, err := r.revisionSelect(columns, commentID).OrderBy("created_at DESC", "id DESC").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if tx == nil {
+		rows, err = repository.DB.QueryContext(ctx, p.PaginateQuery(query), values...)
+	} else {
+		rows, err = tx.QueryContext(ctx, p.PaginateQuery(query), values...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	results := []comment.Revision{}
+	for rows.Next() {
+		result, err := r.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (r *repositoryImpl) Count(ctx context.Context, tx *sql.Tx, commentID int) (int, error) {
+	countQ, values, err := r.revisionSelect("count(1)", commentID).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	if tx == nil {
+		err = repository.DB.QueryRowContext(ctx, countQ, values...).Scan(&count)
+	} else {
+		err = tx.QueryRowContext(ctx, countQ, values...).Scan(&count)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repositoryImpl) DeleteByComments(ctx context.Context, tx *sql.Tx, commentIDs []int) error {
+	delete, values, err := repository.Psq.Delete("comment_revision").Where(sq.Eq{"comment_id": commentIDs}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, delete, values...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryImpl) revisionSelect(columns string, commentID int) sq.SelectBuilder {
+	return repository.Psq.Select(columns).From("comment_revision").Where(sq.Eq{"comment_id": commentID})
+}
+
+func (r *repositoryImpl) scanRow(rows *sql.Rows) (comment.Revision, error) {
+	result := comment.Revision{}
+	actionValue := ""
+	tpValue := ""
+	err := rows.Scan(
+		&result.ID,
+		&result.CommentID,
+		&actionValue,
+		&tpValue,
+		&result.Description,
+		&result.ChangedBy,
+		&result.CreatedAt,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	action, err := comment.RevisionActionValueOf(actionValue)
+	if err != nil {
+		return result, err
+	}
+	result.Action = action
+
+	tp, err := comment.TypeValueOf(tpValue)
+	if err != nil {
+		return result, err
+	}
+	result.Type = tp
+
+	return result, nil
+}
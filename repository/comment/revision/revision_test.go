@@ -0,0 +1,150 @@
+package revision_test
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/common/pagination"
+	"go-boilerplate/domain/comment"
+	"go-boilerplate/repository"
+	commentRepository "go-boilerplate/repository/comment"
+	"go-boilerplate/repository/comment/revision"
+	"os"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var impl = revision.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+// anyRevision persists a revision of a persisted comment and register its cleanup in the given test
+func anyRevision(t *testing.T) comment.Revision {
+	cmt := commentRepository.Any(t)
+	rev := comment.NewRevision(cmt, comment.RevisionUpdate, gofakeit.UUID())
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		ID, err := impl.Insert(context.Background(), tx, rev)
+		if err != nil {
+			t.Errorf("error inserting comment revision test data %s", err)
+		}
+		rev.ID = ID
+	})
+
+	t.Cleanup(func() {
+		revision.DeleteTestData(t, cmt.ID)
+	})
+	return rev
+}
+
+func TestInsert(t *testing.T) {
+	cmt := commentRepository.Any(t)
+	t.Cleanup(func() {
+		revision.DeleteTestData(t, cmt.ID)
+	})
+
+	testCases := []struct {
+		name     string
+		revision comment.Revision
+	}{
+		{
+			name:     "comment revision inserted successfully",
+			revision: comment.NewRevision(cmt, comment.RevisionDelete, gofakeit.UUID()),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository.Tx(t, func(tx *sql.Tx) {
+				ID, err := impl.Insert(context.Background(), tx, tc.revision)
+				if err != nil {
+					t.Errorf("unexpected error inserting comment revision %s", err)
+					return
+				}
+
+				if ID == 0 {
+					t.Errorf("unexpected comment revision id %d", ID)
+					return
+				}
+			})
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	rev := anyRevision(t)
+	p, _ := pagination.New(0, 30)
+
+	testCases := []struct {
+		name      string
+		commentID int
+		p         pagination.Pagination
+		expected  []comment.Revision
+	}{
+		{
+			name:      "found revisions by comment",
+			commentID: rev.CommentID,
+			p:         p,
+			expected: []comment.Revision{
+				rev,
+			},
+		},
+		{
+			name:      "no revisions found",
+			commentID: -1,
+			p:         p,
+			expected:  []comment.Revision{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := impl.Find(context.Background(), nil, tc.commentID, tc.p)
+			if err != nil {
+				t.Errorf("unexpected error finding comment revisions %s", err)
+				return
+			}
+
+			if diff := cmp.Diff(result, tc.expected, cmpopts.IgnoreFields(comment.Revision{}, "CreatedAt")); diff != "" {
+				t.Errorf("unexpected comment revisions result %s", diff)
+				return
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	rev := anyRevision(t)
+
+	testCases := []struct {
+		name      string
+		commentID int
+		expected  int
+	}{
+		{
+			name:      "count 1 revision by comment",
+			commentID: rev.CommentID,
+			expected:  1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := impl.Count(context.Background(), nil, tc.commentID)
+			if err != nil {
+				t.Errorf("unexpected error counting comment revisions %s", err)
+				return
+			}
+
+			if result != tc.expected {
+				t.Errorf("unexpected comment revisions count result %d", result)
+				return
+			}
+		})
+	}
+}
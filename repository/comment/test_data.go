@@ -1,6 +1,7 @@
 package comment
 
 import (
+	"context"
 	"database/sql"
 	"go-boilerplate/domain/comment"
 	"go-boilerplate/repository"
@@ -16,7 +17,7 @@ func Any(t *testing.T) comment.Comment {
 	ID := 0
 
 	repository.Tx(t, func(tx *sql.Tx) {
-		id, err := r.Insert(tx, cmt)
+		id, err := r.Insert(context.Background(), tx, cmt)
 		if err != nil {
 			t.Errorf("error inserting comment test data %s", err)
 		}
@@ -27,13 +28,40 @@ func Any(t *testing.T) comment.Comment {
 		DeleteTestData(t, ID)
 	})
 	cmt.ID = ID
+	cmt.Version = 1
+	return cmt
+}
+
+// AnyReply returns a persisted reply to the given comment and register its necessary cleanup in the given test
+func AnyReply(t *testing.T, parent comment.Comment) comment.Comment {
+	cmt := fixtures.AnyComment()
+	cmt.AdvertiserID = parent.AdvertiserID
+	cmt.AccountID = parent.AccountID
+	cmt.ListingID = parent.ListingID
+	cmt.ParentID = &parent.ID
+	cmt.Depth = parent.Depth + 1
+	ID := 0
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		id, err := r.Insert(context.Background(), tx, cmt)
+		if err != nil {
+			t.Errorf("error inserting reply test data %s", err)
+		}
+		ID = id
+	})
+
+	t.Cleanup(func() {
+		DeleteTestData(t, ID)
+	})
+	cmt.ID = ID
+	cmt.Version = 1
 	return cmt
 }
 
 // DeleteTestData deletes some previous test data
 func DeleteTestData(t *testing.T, ID int) {
 	repository.Tx(t, func(tx *sql.Tx) {
-		err := r.Delete(tx, ID)
+		_, err := tx.Exec("DELETE FROM comment WHERE id = $1", ID)
 		if err != nil {
 			t.Errorf("error cleaning up comment test data %s", err)
 		}
@@ -44,7 +72,7 @@ func DeleteTestData(t *testing.T, ID int) {
 func Comment(t *testing.T, ID int) comment.Comment {
 	cmt := comment.Comment{}
 	repository.Tx(t, func(tx *sql.Tx) {
-		data, err := r.FindByID(tx, ID)
+		data, err := r.FindByID(context.Background(), tx, ID, true)
 		if err != nil {
 			t.Errorf("error getting comment test data %s", err)
 		}
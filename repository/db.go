@@ -2,13 +2,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"go-boilerplate/common"
+	"go-boilerplate/common/metrics"
 	"sync/atomic"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 const pgUniqueViolationSQLState = "23505"
@@ -28,6 +32,9 @@ var (
 	DB *sql.DB
 
 	dbReady = int32(0)
+
+	// dbStats exposes the pool stats of the current db instance (eg go_sql_in_use_connections)
+	dbStats prometheus.Collector
 )
 
 // OrderDirection of the given order type
@@ -85,11 +92,25 @@ func setupDB() error {
 	if err != nil {
 		return err
 	}
+	dbStats = collectors.NewDBStatsCollector(db, dbName)
+	err = metrics.Registry.Register(dbStats)
+	if err != nil {
+		return err
+	}
 	dbIsReady()
 
 	return nil
 }
 
+func closeDB() error {
+	if !isDBReady() {
+		return nil
+	}
+	atomic.StoreInt32(&dbReady, 0)
+	metrics.Registry.Unregister(dbStats)
+	return DB.Close()
+}
+
 func dbHealthcheck() (int, error) {
 	result := 0
 	err := DB.QueryRow("SELECT 1").Scan(&result)
@@ -105,12 +126,25 @@ func CloseRows(rows *sql.Rows) {
 
 // IsUniqueConstraintViolation checks if the given error is a sql constraint violation
 func IsUniqueConstraintViolation(err error) bool {
-	if pgerr, ok := err.(*pgconn.PgError); ok {
+	var pgerr *pgconn.PgError
+	if errors.As(err, &pgerr) {
 		return pgerr.SQLState() == pgUniqueViolationSQLState
 	}
 	return false
 }
 
+// CheckRowsAffected returns ErrNotFound when the given statement result affected no rows
+func CheckRowsAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // GetOrderByStatusClause given a table alias and slice of status strings generates an order by clause
 func GetOrderByStatusClause(alias string, orderByStatusWeights [][]string) string {
 	clause := fmt.Sprintf("case %s.last_status ", alias)
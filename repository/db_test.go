@@ -1,10 +1,15 @@
 package repository_test
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"go-boilerplate/repository"
+	"go-boilerplate/test"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/jackc/pgconn"
 )
 
 func TestGetOrderByStatusClause(t *testing.T) {
@@ -42,3 +47,74 @@ func TestGetOrderByStatusClause(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUniqueConstraintViolation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "unique violation",
+			err:      &pgconn.PgError{Code: "23505"},
+			expected: true,
+		},
+		{
+			name:     "wrapped unique violation",
+			err:      fmt.Errorf("error inserting: %w", &pgconn.PgError{Code: "23505"}),
+			expected: true,
+		},
+		{
+			name:     "another pg error",
+			err:      &pgconn.PgError{Code: "23503"},
+			expected: false,
+		},
+		{
+			name:     "not a pg error",
+			err:      errors.New("any"),
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := repository.IsUniqueConstraintViolation(tc.err)
+			if result != tc.expected {
+				t.Errorf("unexpected unique violation result %t", result)
+			}
+		})
+	}
+}
+
+type rowsAffectedResult int64
+
+func (r rowsAffectedResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (r rowsAffectedResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+func TestCheckRowsAffected(t *testing.T) {
+	testCases := []struct {
+		name          string
+		result        sql.Result
+		expectedError error
+	}{
+		{
+			name:   "some rows affected",
+			result: rowsAffectedResult(1),
+		},
+		{
+			name:          "no rows affected",
+			result:        rowsAffectedResult(0),
+			expectedError: repository.ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := repository.CheckRowsAffected(tc.result)
+			test.AssertErrorType(t, err, tc.expectedError)
+		})
+	}
+}
@@ -2,13 +2,10 @@ package repository
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"go-boilerplate/common"
 	"io"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"strings"
@@ -27,7 +24,6 @@ const (
 var (
 	httpTimeout             = common.Config.GetInt("httpTimeoutSeconds")
 	httpHealthcheckEndpoint = common.Config.Get("httpHealthcheckEndpoint")
-	httpMaxRetries          = common.Config.GetInt("httpMaxRetries")
 )
 
 var (
@@ -39,14 +35,17 @@ var (
 
 var httpTimeoutDuration = time.Duration(httpTimeout) * time.Second
 
+var httpDialer = timeoutDialer(httpTimeoutDuration, httpTimeoutDuration)
+
+// customHTTPTransport dials TLS connections itself so each host gets its own TLS settings (see ConfigureTLS)
 var customHTTPTransport = &http.Transport{
 	Proxy:                 http.ProxyFromEnvironment,
-	DialContext:           timeoutDialer(httpTimeoutDuration, httpTimeoutDuration),
+	DialContext:           httpDialer,
+	DialTLSContext:        tlsDialer(httpDialer),
 	MaxIdleConns:          common.Config.GetInt("httpMinConnections") * 10,
 	MaxIdleConnsPerHost:   common.Config.GetInt("httpMaxConnections"),
 	IdleConnTimeout:       httpTimeoutDuration,
 	TLSHandshakeTimeout:   httpTimeoutDuration,
-	TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
 	ExpectContinueTimeout: httpTimeoutDuration,
 }
 
@@ -101,10 +100,19 @@ func isHTTPReady() bool {
 	return atomic.LoadInt32(&httpReady) == 1
 }
 
-func setupHTTP() {
+func setupHTTP() error {
 	if isHTTPReady() {
-		return
+		return nil
+	}
+	options, err := tlsOptionsFromConfig()
+	if err != nil {
+		return err
+	}
+	err = ConfigureTLS(options)
+	if err != nil {
+		return err
 	}
+
 	HTTP = &http.Client{
 		Transport: customHTTPTransport,
 		Timeout:   httpTimeoutDuration,
@@ -117,71 +125,55 @@ func setupHTTP() {
 		}),
 	)
 	httpIsReady()
+
+	return nil
+}
+
+func closeHTTP() {
+	if !isHTTPReady() {
+		return
+	}
+	HTTP.CloseIdleConnections()
+	atomic.StoreInt32(&httpReady, 0)
 }
 
-// ExecuteAndParseHTTPResponse executes the given request with default http instance
+// ExecuteAndParseHTTPResponse executes the given request with default http instance, prefer Request which is typed
+// and bound to a context. Errors of statuses known by the repository are returned as they are (eg ErrNotFound)
 func ExecuteAndParseHTTPResponse(
 	method, url string,
 	result interface{},
 	body io.Reader,
 	header *http.Header,
 	timeout time.Duration,
-	attempt int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	request, err := http.NewRequestWithContext(ctx, method, url, body)
-	if err != nil {
-		return err
-	}
+	retry int) error {
+	request := Request[json.RawMessage](context.Background()).
+		Method(method).
+		URL(url).
+		Timeout(timeout).
+		Retry(retry == retryEnabled)
 	if header != nil {
-		request.Header = *header
+		request.Headers(*header)
 	}
-	response, err := HTTP.Do(request)
-	defer CloseBody(response)
-	if err != nil {
-		if attempt >= retryEnabled && attempt <= httpMaxRetries {
-			common.Logger.Warnf("retrying, method: %s, url: %s, attempt: %d, timeout: %v, err: %v", method, url, attempt, timeout, err)
-			attempt++
-			time.Sleep(time.Duration(attempt) * time.Second)
-			return ExecuteAndParseHTTPResponse(method, url, result, body, header, timeout, attempt)
-		}
-		return err
+	if body != nil {
+		request.Body(body)
+	}
+
+	content, err := request.Do()
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && errors.Unwrap(httpErr) != nil {
+		return errors.Unwrap(httpErr)
 	}
-	bytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return err
 	}
-	if response.StatusCode == http.StatusNotFound {
-		common.Logger.Errorf("error executing %s %s - %d - %s", method, url, response.StatusCode, string(bytes))
-		return ErrNotFound
-	}
-	if response.StatusCode == http.StatusUnauthorized {
-		common.Logger.Errorf("error executing %s %s - %d - %s", method, url, response.StatusCode, string(bytes))
-		return ErrUnauthorizedResource
-	}
-	if response.StatusCode == http.StatusForbidden {
-		common.Logger.Errorf("error executing %s %s - %d - %s", method, url, response.StatusCode, string(bytes))
-		return ErrUnauthorizedResource
-	}
-	if response.StatusCode == http.StatusUnprocessableEntity {
-		common.Logger.Errorf("error executing %s %s - %d - %s", method, url, response.StatusCode, string(bytes))
-		return ErrUnprocessableEntityResource
-	}
-	if response.StatusCode >= http.StatusMultipleChoices {
-		return fmt.Errorf("error executing %s %s - %d - %s", method, url, response.StatusCode, string(bytes))
-	}
-
-	if common.Config.Get("httpResponseDebug") == "true" {
-		common.Logger.Debugf("response from %s %s - %s", method, url, string(bytes))
-	}
 
 	if result != nil {
-		if len(bytes) == 0 {
+		if len(content) == 0 {
 			common.Logger.Warnf("response from %s %s has an empty body", method, url)
 			return nil
 		}
 
-		err = json.Unmarshal(bytes, &result)
+		err = json.Unmarshal(content, &result)
 		if err != nil {
 			return err
 		}
@@ -222,7 +214,7 @@ func CloseBody(response *http.Response) {
 	}
 	err := response.Body.Close()
 	if err != nil {
-		common.Logger.Errorf("error closing response body", err)
+		common.Logger.Errorf("error closing response body %s", err)
 	}
 }
 
@@ -1,6 +1,8 @@
 package repository_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go-boilerplate/repository"
 	"go-boilerplate/test"
@@ -35,6 +37,7 @@ type anything struct {
 
 func TestExecuteAndParseHTTPResponse(t *testing.T) {
 	retrySlowWait := 3
+	unavailableOnce := true
 	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/error" {
 			b := []byte("ERROR")
@@ -76,6 +79,22 @@ func TestExecuteAndParseHTTPResponse(t *testing.T) {
 			w.Write(b)
 			return
 		}
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("UNAVAILABLE"))
+			return
+		}
+		if r.URL.Path == "/unavailable-once" {
+			if unavailableOnce {
+				unavailableOnce = false
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":1,"desc":"anything"}`))
+			return
+		}
 		if r.URL.Path == "/empty-response" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -166,6 +185,28 @@ func TestExecuteAndParseHTTPResponse(t *testing.T) {
 			expectedError: "",
 			retry:         retryEnabled,
 		},
+		{
+			name:    "get executed successfully after retrying an unavailable service",
+			method:  http.MethodGet,
+			path:    "unavailable-once",
+			timeout: 1 * time.Second,
+			expected: anything{
+				ID:   1,
+				Desc: "anything",
+			},
+			expectedError: "",
+			retry:         retryEnabled,
+		},
+		{
+			name:          "post not retried when service is unavailable",
+			method:        http.MethodPost,
+			path:          "unavailable",
+			timeout:       1 * time.Second,
+			body:          strings.NewReader(`{"a":1,"b":"c"}`),
+			expected:      anything{},
+			expectedError: "error executing POST http://127.0.0.1:8001/unavailable - 503 - UNAVAILABLE",
+			retry:         retryDisabled,
+		},
 		{
 			name:          "empty body response when some data is expected",
 			method:        http.MethodGet,
@@ -197,3 +238,97 @@ func TestExecuteAndParseHTTPResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestCircuitBreaker(t *testing.T) {
+	host := "127.0.0.1:8001"
+	requests := 0
+	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	repository.SetHTTPPolicy(host, repository.HTTPPolicy{
+		MaxRetries:       1,
+		Backoff:          10 * time.Millisecond,
+		MaxBackoff:       10 * time.Millisecond,
+		FailureThreshold: 2,
+		OpenTimeout:      500 * time.Millisecond,
+	})
+	t.Cleanup(func() {
+		repository.SetHTTPPolicy(host, repository.DefaultHTTPPolicy)
+	})
+
+	err := repository.GetAndParseHTTPResponse("http://127.0.0.1:8001/down", nil, nil, time.Second)
+	if err == nil || requests != 2 {
+		t.Errorf("unexpected result of failing request %d %v", requests, err)
+	}
+	if state := repository.Healthcheck().Circuits[host]; state != repository.CircuitOpen.String() {
+		t.Errorf("unexpected circuit state %s", state)
+	}
+
+	err = repository.GetAndParseHTTPResponse("http://127.0.0.1:8001/up", nil, nil, time.Second)
+	if !errors.Is(err, repository.ErrCircuitOpen) || requests != 2 {
+		t.Errorf("unexpected result of request with the circuit open %d %v", requests, err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	if state := repository.Healthcheck().Circuits[host]; state != repository.CircuitHalfOpen.String() {
+		t.Errorf("unexpected circuit state %s", state)
+	}
+
+	err = repository.GetAndParseHTTPResponse("http://127.0.0.1:8001/up", nil, nil, time.Second)
+	if err != nil || requests != 3 {
+		t.Errorf("unexpected result of probe request %d %v", requests, err)
+	}
+	if _, ok := repository.Healthcheck().Circuits[host]; ok {
+		t.Errorf("unexpected circuit not closed after probe")
+	}
+}
+
+func TestCircuitBreakerProbeCanceled(t *testing.T) {
+	host := "127.0.0.1:8001"
+	requests := 0
+	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		}
+	})
+	repository.SetHTTPPolicy(host, repository.HTTPPolicy{
+		FailureThreshold: 1,
+		OpenTimeout:      200 * time.Millisecond,
+	})
+	t.Cleanup(func() {
+		repository.SetHTTPPolicy(host, repository.DefaultHTTPPolicy)
+	})
+
+	_, err := repository.Request[anything](context.Background()).URL("http://127.0.0.1:8001/down").Do()
+	if err == nil || requests != 1 {
+		t.Errorf("unexpected result of failing request %d %v", requests, err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = repository.Request[anything](ctx).URL("http://127.0.0.1:8001/slow").Do()
+	if !errors.Is(err, context.DeadlineExceeded) || requests != 2 {
+		t.Errorf("unexpected result of canceled probe request %d %v", requests, err)
+	}
+	if state := repository.Healthcheck().Circuits[host]; state != repository.CircuitHalfOpen.String() {
+		t.Errorf("unexpected circuit state %s", state)
+	}
+
+	_, err = repository.Request[anything](context.Background()).URL("http://127.0.0.1:8001/up").Do()
+	if err != nil || requests != 3 {
+		t.Errorf("unexpected result of probe request %d %v", requests, err)
+	}
+	if _, ok := repository.Healthcheck().Circuits[host]; ok {
+		t.Errorf("unexpected circuit not closed after probe")
+	}
+}
@@ -0,0 +1,176 @@
+// Package idempotency holds data access logic of the idempotency keys sent by clients along creation requests
+package idempotency
+
+import (
+	"context"
+	sql "database/sql"
+	"errors"
+	"go-boilerplate/domain/idempotency"
+	"go-boilerplate/repository"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	columns = `
+		scope,
+		key,
+		request_hash,
+		coalesce(resource_id, 0),
+		coalesce(response_status, 0),
+		response_body,
+		created_at
+	`
+)
+
+var (
+	instance = &repositoryImpl{}
+)
+
+// Repository to enable this repository to be mocked
+type Repository interface {
+	// Reserve the given key for a request, a key created before the given expiration is replaced.
+	// It's false when the key is already in use, a key being reserved by another transaction blocks until it's resolved
+	Reserve(ctx context.Context, tx *sql.Tx, k idempotency.Key, expiredBefore time.Time) (bool, error)
+	// Complete a reserved key with the resource created by its request and the response to it
+	Complete(ctx context.Context, tx *sql.Tx, scope, key string, resourceID int, resp idempotency.Response) error
+	// FindByKey of the given scope
+	FindByKey(ctx context.Context, tx *sql.Tx, scope, key string) (idempotency.Key, error)
+	// DeleteByResources deletes the keys of the scopes starting with the given prefix which created the given resources
+	DeleteByResources(ctx context.Context, tx *sql.Tx, scopePrefix string, resourceIDs []int) error
+	// Purge deletes keys created before the given time, returning how many were deleted
+	Purge(ctx context.Context, tx *sql.Tx, before time.Time) (int, error)
+}
+
+type repositoryImpl struct{}
+
+// Get this repository instance
+func Get() Repository {
+	return instance
+}
+
+func (r *repositoryImpl) Reserve(ctx context.Context, tx *sql.Tx, k idempotency.Key, expiredBefore time.Time) (bool, error) {
+	insert, values, err := repository.Psq.Insert("idempotency_key").Columns(`
+		scope,
+		key,
+		request_hash,
+		created_at
+	`).Values(
+		k.Scope,
+		k.Key,
+		k.RequestHash,
+		time.Now(),
+	).Suffix(`ON CONFLICT (scope, key) DO UPDATE SET
+		request_hash = excluded.request_hash,
+		resource_id = NULL,
+		response_status = NULL,
+		response_body = NULL,
+		created_at = excluded.created_at
+		WHERE idempotency_key.created_at < ?
+		RETURNING key`, expiredBefore).ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	key := ""
+	err = tx.QueryRowContext(ctx, insert, values...).Scan(&key)
+	// neither inserted nor replaced
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *repositoryImpl) Complete(ctx context.Context, tx *sql.Tx, scope, key string, resourceID int, resp idempotency.Response) error {
+	update, values, err := repository.Psq.Update("idempotency_key").
+		Set("resource_id", resourceID).
+		Set("response_status", resp.Status).
+		Set("response_body", resp.Body).
+		Where(sq.Eq{"scope": scope, "key": key}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, update, values...)
+	if err != nil {
+		return err
+	}
+
+	return repository.CheckRowsAffected(result)
+}
+
+func (r *repositoryImpl) FindByKey(ctx context.Context, tx *sql.Tx, scope, key string) (idempotency.Key, error) {
+	query, values, err := repository.Psq.Select(columns).From("idempotency_key").
+		Where(sq.Eq{"scope": scope, "key": key}).
+		ToSql()
+	if err != nil {
+		return idempotency.Key{}, err
+	}
+
+	var row *sql.Row
+	if tx == nil {
+		row = repository.DB.QueryRowContext(ctx, query, values...)
+	} else {
+		row = tx.QueryRowContext(ctx, query, values...)
+	}
+
+	result := idempotency.Key{}
+	err = row.Scan(
+		&result.Scope,
+		&result.Key,
+		&result.RequestHash,
+		&result.ResourceID,
+		&result.Response.Status,
+		&result.Response.Body,
+		&result.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return idempotency.Key{}, repository.ErrNotFound
+	}
+	if err != nil {
+		return idempotency.Key{}, err
+	}
+
+	return result, nil
+}
+
+func (r *repositoryImpl) DeleteByResources(ctx context.Context, tx *sql.Tx, scopePrefix string, resourceIDs []int) error {
+	delete, values, err := repository.Psq.Delete("idempotency_key").
+		Where("starts_with(scope, ?)", scopePrefix).
+		Where(sq.Eq{"resource_id": resourceIDs}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, delete, values...)
+	return err
+}
+
+func (r *repositoryImpl) Purge(ctx context.Context, tx *sql.Tx, before time.Time) (int, error) {
+	delete, values, err := repository.Psq.Delete("idempotency_key").
+		Where(sq.Lt{"created_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var result sql.Result
+	if tx == nil {
+		result, err = repository.DB.ExecContext(ctx, delete, values...)
+	} else {
+		result, err = tx.ExecContext(ctx, delete, values...)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	return int(count), err
+}
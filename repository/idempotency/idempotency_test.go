@@ -0,0 +1,146 @@
+package idempotency_test
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/domain/idempotency"
+	"go-boilerplate/repository"
+	idempotencyRepository "go-boilerplate/repository/idempotency"
+	"go-boilerplate/test"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var impl = idempotencyRepository.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestReserve(t *testing.T) {
+	scope := gofakeit.UUID()
+	t.Cleanup(func() {
+		idempotencyRepository.DeleteTestData(t, scope)
+	})
+	k := idempotency.Key{Scope: scope, Key: gofakeit.UUID(), RequestHash: "first"}
+	resp := idempotency.Response{Status: 201, Body: []byte(`{"id":10}`)}
+
+	testCases := []struct {
+		name          string
+		key           idempotency.Key
+		expiredBefore time.Time
+		expected      bool
+		expectedKey   idempotency.Key
+	}{
+		{
+			name:          "new key reserved",
+			key:           k,
+			expiredBefore: time.Now().Add(-time.Hour),
+			expected:      true,
+			expectedKey:   idempotency.Key{Scope: scope, Key: k.Key, RequestHash: "first", ResourceID: 10, Response: resp},
+		},
+		{
+			name:          "key in use",
+			key:           idempotency.Key{Scope: scope, Key: k.Key, RequestHash: "second"},
+			expiredBefore: time.Now().Add(-time.Hour),
+			expectedKey:   idempotency.Key{Scope: scope, Key: k.Key, RequestHash: "first", ResourceID: 10, Response: resp},
+		},
+		{
+			name:          "expired key replaced",
+			key:           idempotency.Key{Scope: scope, Key: k.Key, RequestHash: "third"},
+			expiredBefore: time.Now().Add(time.Hour),
+			expected:      true,
+			expectedKey:   idempotency.Key{Scope: scope, Key: k.Key, RequestHash: "third", ResourceID: 10, Response: resp},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository.Tx(t, func(tx *sql.Tx) {
+				result, err := impl.Reserve(context.Background(), tx, tc.key, tc.expiredBefore)
+				test.AssertError(t, err, "")
+				if result != tc.expected {
+					t.Errorf("unexpected reserve result %t", result)
+				}
+				if result {
+					err = impl.Complete(context.Background(), tx, tc.key.Scope, tc.key.Key, 10, resp)
+					test.AssertError(t, err, "")
+				}
+			})
+
+			result, err := impl.FindByKey(context.Background(), nil, tc.key.Scope, tc.key.Key)
+			test.AssertError(t, err, "")
+			if diff := cmp.Diff(result, tc.expectedKey, cmpopts.IgnoreFields(idempotency.Key{}, "CreatedAt")); diff != "" {
+				t.Errorf("unexpected key %s", diff)
+			}
+		})
+	}
+}
+
+func TestFindByKey(t *testing.T) {
+	_, err := impl.FindByKey(context.Background(), nil, gofakeit.UUID(), gofakeit.UUID())
+	test.AssertErrorType(t, err, repository.ErrNotFound)
+}
+
+func TestPurge(t *testing.T) {
+	scope := gofakeit.UUID()
+	t.Cleanup(func() {
+		idempotencyRepository.DeleteTestData(t, scope)
+	})
+	k := idempotency.Key{Scope: scope, Key: gofakeit.UUID(), RequestHash: "hash"}
+	repository.Tx(t, func(tx *sql.Tx) {
+		_, err := impl.Reserve(context.Background(), tx, k, time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Errorf("error inserting idempotency key test data %s", err)
+		}
+	})
+
+	count, err := impl.Purge(context.Background(), nil, time.Now().Add(time.Second))
+	test.AssertError(t, err, "")
+	if count < 1 {
+		t.Errorf("unexpected purged keys %d", count)
+	}
+
+	_, err = impl.FindByKey(context.Background(), nil, scope, k.Key)
+	test.AssertErrorType(t, err, repository.ErrNotFound)
+}
+
+func TestDeleteByResources(t *testing.T) {
+	scope := "comment:" + gofakeit.UUID()
+	otherScope := "other:" + gofakeit.UUID()
+	t.Cleanup(func() {
+		idempotencyRepository.DeleteTestData(t, scope)
+		idempotencyRepository.DeleteTestData(t, otherScope)
+	})
+	k := idempotency.Key{Scope: scope, Key: gofakeit.UUID(), RequestHash: "hash"}
+	other := idempotency.Key{Scope: otherScope, Key: gofakeit.UUID(), RequestHash: "hash"}
+	repository.Tx(t, func(tx *sql.Tx) {
+		for _, key := range []idempotency.Key{k, other} {
+			_, err := impl.Reserve(context.Background(), tx, key, time.Now().Add(-time.Hour))
+			if err == nil {
+				err = impl.Complete(context.Background(), tx, key.Scope, key.Key, 10, idempotency.Response{Status: 201})
+			}
+			if err != nil {
+				t.Errorf("error inserting idempotency key test data %s", err)
+			}
+		}
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.DeleteByResources(context.Background(), tx, "comment:", []int{10})
+		test.AssertError(t, err, "")
+	})
+
+	_, err := impl.FindByKey(context.Background(), nil, scope, k.Key)
+	test.AssertErrorType(t, err, repository.ErrNotFound)
+	_, err = impl.FindByKey(context.Background(), nil, otherScope, other.Key)
+	test.AssertError(t, err, "")
+}
@@ -0,0 +1,17 @@
+package idempotency
+
+import (
+	"database/sql"
+	"go-boilerplate/repository"
+	"testing"
+)
+
+// DeleteTestData deletes keys of a given scope created by some test
+func DeleteTestData(t *testing.T, scope string) {
+	repository.Tx(t, func(tx *sql.Tx) {
+		_, err := tx.Exec("DELETE FROM idempotency_key WHERE scope = $1", scope)
+		if err != nil {
+			t.Errorf("error cleaning up idempotency key test data %s", err)
+		}
+	})
+}
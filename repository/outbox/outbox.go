@@ -0,0 +1,206 @@
+// Package outbox holds data access logic of events waiting to be published to the queue
+package outbox
+
+import (
+	"context"
+	sql "database/sql"
+	"go-boilerplate/domain/outbox"
+	"go-boilerplate/repository"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	columns = `
+		id,
+		aggregate,
+		aggregate_id,
+		type,
+		payload,
+		status,
+		attempts,
+		coalesce(last_error, ''),
+		available_at,
+		created_at,
+		published_at
+	`
+)
+
+var (
+	instance = &repositoryImpl{}
+)
+
+// Repository to enable this repository to be mocked
+type Repository interface {
+	// Insert a pending event available to be published right away
+	Insert(ctx context.Context, tx *sql.Tx, e outbox.Event) (int, error)
+	// FindPending locks pending events available to be published, oldest first, limited to the given IDs when they are set.
+	// Events locked by other transactions are skipped
+	FindPending(ctx context.Context, tx *sql.Tx, IDs []int, limit int) ([]outbox.Event, error)
+	// MarkPublished flags the given events as published
+	MarkPublished(ctx context.Context, tx *sql.Tx, IDs []int) error
+	// MarkFailed records a failed publish attempt of the given event, with its status, attempts, last error and next availability
+	MarkFailed(ctx context.Context, tx *sql.Tx, e outbox.Event) error
+	// Purge deletes events published before the given time, returning how many were deleted
+	Purge(ctx context.Context, tx *sql.Tx, before time.Time) (int, error)
+}
+
+type repositoryImpl struct{}
+
+// Get this repository instance
+func Get() Repository {
+	return instance
+}
+
+func (r *repositoryImpl) Insert(ctx context.Context, tx *sql.Tx, e outbox.Event) (int, error) {
+	now := time.Now()
+	insert, values, err := repository.Psq.Insert("outbox").Columns(`
+		aggregate,
+		aggregate_id,
+		type,
+		payload,
+		status,
+		available_at,
+		created_at
+	`).Values(
+		e.Aggregate,
+		e.AggregateID,
+		e.Type,
+		[]byte(e.Payload),
+		outbox.Pending.String(),
+		now,
+		now,
+	).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	ID := 0
+	err = tx.QueryRowContext(ctx, insert, values...).Scan(&ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, nil
+}
+
+func (r *repositoryImpl) FindPending(ctx context.Context, tx *sql.Tx, IDs []int, limit int) ([]outbox.Event, error) {
+	selectQ := repository.Psq.Select(columns).From("outbox").
+		Where(sq.Eq{"status": outbox.Pending.String()}).
+		Where(sq.LtOrEq{"available_at": time.Now()})
+	if len(IDs) > 0 {
+		selectQ = selectQ.Where(sq.Eq{"id": IDs})
+	}
+
+	query, values, err := selectQ.OrderBy("id").Limit(uint64(limit)).Suffix("FOR UPDATE SKIP LOCKED").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer repository.CloseRows(rows)
+
+	results := []outbox.Event{}
+	for rows.Next() {
+		result, err := r.scanRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *repositoryImpl) MarkPublished(ctx context.Context, tx *sql.Tx, IDs []int) error {
+	update, values, err := repository.Psq.Update("outbox").
+		Set("status", outbox.Published.String()).
+		Set("published_at", time.Now()).
+		Where(sq.Eq{"id": IDs}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, update, values...)
+	return err
+}
+
+func (r *repositoryImpl) MarkFailed(ctx context.Context, tx *sql.Tx, e outbox.Event) error {
+	update, values, err := repository.Psq.Update("outbox").
+		Set("status", e.Status.String()).
+		Set("attempts", e.Attempts).
+		Set("last_error", e.LastError).
+		Set("available_at", e.AvailableAt).
+		Where(sq.Eq{"id": e.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, update, values...)
+	if err != nil {
+		return err
+	}
+
+	return repository.CheckRowsAffected(result)
+}
+
+func (r *repositoryImpl) Purge(ctx context.Context, tx *sql.Tx, before time.Time) (int, error) {
+	delete, values, err := repository.Psq.Delete("outbox").
+		Where(sq.Eq{"status": outbox.Published.String()}).
+		Where(sq.Lt{"published_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var result sql.Result
+	if tx == nil {
+		result, err = repository.DB.ExecContext(ctx, delete, values...)
+	} else {
+		result, err = tx.ExecContext(ctx, delete, values...)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+func (r *repositoryImpl) scanRow(rows *sql.Rows) (outbox.Event, error) {
+	result := outbox.Event{}
+	payload := []byte{}
+	statusValue := ""
+	err := rows.Scan(
+		&result.ID,
+		&result.Aggregate,
+		&result.AggregateID,
+		&result.Type,
+		&payload,
+		&statusValue,
+		&result.Attempts,
+		&result.LastError,
+		&result.AvailableAt,
+		&result.CreatedAt,
+		&result.PublishedAt,
+	)
+	if err != nil {
+		return outbox.Event{}, err
+	}
+
+	status, err := outbox.StatusValueOf(statusValue)
+	if err != nil {
+		return outbox.Event{}, err
+	}
+	result.Status = status
+	result.Payload = payload
+
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-boilerplate/domain/outbox"
+	"go-boilerplate/repository"
+	outboxRepository "go-boilerplate/repository/outbox"
+	"go-boilerplate/test"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var impl = outboxRepository.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestFindPending(t *testing.T) {
+	e := outboxRepository.Any(t)
+
+	testCases := []struct {
+		name     string
+		IDs      []int
+		expected []outbox.Event
+	}{
+		{
+			name: "found pending events by id",
+			IDs:  []int{e.ID},
+			expected: []outbox.Event{
+				e,
+			},
+		},
+		{
+			name:     "no pending events found",
+			IDs:      []int{-1},
+			expected: []outbox.Event{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository.Tx(t, func(tx *sql.Tx) {
+				result, err := impl.FindPending(context.Background(), tx, tc.IDs, 10)
+				if err != nil {
+					t.Errorf("unexpected error finding pending events %s", err)
+					return
+				}
+
+				if diff := cmp.Diff(result, tc.expected, cmpopts.IgnoreFields(outbox.Event{}, "AvailableAt", "CreatedAt")); diff != "" {
+					t.Errorf("unexpected pending events %s", diff)
+				}
+			})
+		})
+	}
+}
+
+func TestFindPendingSkipLocked(t *testing.T) {
+	e := outboxRepository.Any(t)
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		locked, err := impl.FindPending(context.Background(), tx, []int{e.ID}, 10)
+		if err != nil || len(locked) != 1 {
+			t.Errorf("unexpected locked events %v %v", locked, err)
+			return
+		}
+
+		repository.Tx(t, func(other *sql.Tx) {
+			result, err := impl.FindPending(context.Background(), other, []int{e.ID}, 10)
+			if err != nil {
+				t.Errorf("unexpected error finding pending events %s", err)
+				return
+			}
+			if len(result) != 0 {
+				t.Errorf("unexpected events locked by another transaction %v", result)
+			}
+		})
+	})
+}
+
+func TestMarkPublished(t *testing.T) {
+	e := outboxRepository.Any(t)
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.MarkPublished(context.Background(), tx, []int{e.ID})
+		if err != nil {
+			t.Errorf("unexpected error marking events as published %s", err)
+		}
+	})
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		result, err := impl.FindPending(context.Background(), tx, []int{e.ID}, 10)
+		if err != nil || len(result) != 0 {
+			t.Errorf("unexpected pending events after publishing %v %v", result, err)
+		}
+	})
+}
+
+func TestMarkFailed(t *testing.T) {
+	e := outboxRepository.Any(t)
+
+	testCases := []struct {
+		name          string
+		event         outbox.Event
+		expectedError error
+	}{
+		{
+			name:  "failed event retried later",
+			event: e.Failed(errors.New("queue unavailable"), 10, time.Hour, time.Hour, time.Now()),
+		},
+		{
+			name:          "event not found",
+			event:         outbox.Event{ID: -1, Status: outbox.Dead},
+			expectedError: repository.ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repository.Tx(t, func(tx *sql.Tx) {
+				err := impl.MarkFailed(context.Background(), tx, tc.event)
+				test.AssertErrorType(t, err, tc.expectedError)
+			})
+		})
+	}
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		result, err := impl.FindPending(context.Background(), tx, []int{e.ID}, 10)
+		if err != nil || len(result) != 0 {
+			t.Errorf("unexpected pending events before backoff %v %v", result, err)
+		}
+	})
+}
+
+func TestPurge(t *testing.T) {
+	published := outboxRepository.Any(t)
+	pending := outboxRepository.Any(t)
+	repository.Tx(t, func(tx *sql.Tx) {
+		err := impl.MarkPublished(context.Background(), tx, []int{published.ID})
+		if err != nil {
+			t.Errorf("error publishing outbox test data %s", err)
+		}
+	})
+
+	count, err := impl.Purge(context.Background(), nil, time.Now().Add(time.Second))
+	test.AssertError(t, err, "")
+	if count < 1 {
+		t.Errorf("unexpected purged events %d", count)
+	}
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		remaining := []int{}
+		rows, err := tx.Query("SELECT id FROM outbox WHERE id = ANY($1)", []int{published.ID, pending.ID})
+		if err != nil {
+			t.Errorf("error finding events after purge %s", err)
+			return
+		}
+		defer repository.CloseRows(rows)
+		for rows.Next() {
+			ID := 0
+			rows.Scan(&ID)
+			remaining = append(remaining, ID)
+		}
+		if diff := cmp.Diff(remaining, []int{pending.ID}); diff != "" {
+			t.Errorf("unexpected events after purge %s", diff)
+		}
+	})
+}
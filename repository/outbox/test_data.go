@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/domain/outbox"
+	"go-boilerplate/repository"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+)
+
+// Any persists a pending event and register its cleanup in the given test
+func Any(t *testing.T) outbox.Event {
+	e := outbox.Event{
+		Aggregate:   "comment",
+		AggregateID: gofakeit.Number(1, 1000),
+		Type:        "CommentCreated",
+		Payload:     []byte(`{}`),
+		Status:      outbox.Pending,
+	}
+
+	repository.Tx(t, func(tx *sql.Tx) {
+		ID, err := instance.Insert(context.Background(), tx, e)
+		if err != nil {
+			t.Errorf("error inserting outbox test data %s", err)
+		}
+		e.ID = ID
+	})
+
+	t.Cleanup(func() {
+		DeleteTestData(t, e.ID)
+	})
+	return e
+}
+
+// DeleteTestData deletes an event created by some test
+func DeleteTestData(t *testing.T, ID int) {
+	repository.Tx(t, func(tx *sql.Tx) {
+		_, err := tx.Exec("DELETE FROM outbox WHERE id = $1", ID)
+		if err != nil {
+			t.Errorf("error cleaning up outbox test data %s", err)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+// Package queue holds access logic of messages sent to and received from SQS
+package queue
+
+import (
+	"context"
+	"go-boilerplate/common"
+	"go-boilerplate/repository"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+var (
+	instance = &queueImpl{
+		events:    common.Config.Get("commentEventsQueue"),
+		queueURLs: map[string]string{},
+	}
+)
+
+// Message sent to a queue
+type Message struct {
+	Body string
+	// Attributes let consumers filter messages without parsing their body
+	Attributes map[string]string
+}
+
+// Received message of a queue, it's hidden from other consumers until its visibility timeout expires
+type Received struct {
+	Message
+	ID            string
+	ReceiptHandle string
+	// ReceiveCount is how many times the message was received, including this one
+	ReceiveCount int
+}
+
+// Publisher to enable this repository to be mocked
+type Publisher interface {
+	// Publish the given message to the events queue
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Consumer to enable this repository to be mocked
+type Consumer interface {
+	// Receive up to max messages of the given queue, waiting for them to arrive until the wait time is over
+	Receive(ctx context.Context, queue string, max int, wait, visibility time.Duration) ([]Received, error)
+	// ExtendVisibility of a received message, keeping it hidden from other consumers for the given time from now
+	ExtendVisibility(ctx context.Context, queue, receiptHandle string, visibility time.Duration) error
+	// Delete a received message once it's processed
+	Delete(ctx context.Context, queue, receiptHandle string) error
+	// Send the given message to a queue
+	Send(ctx context.Context, queue string, msg Message) error
+}
+
+type queueImpl struct {
+	events string
+
+	mu        sync.Mutex
+	queueURLs map[string]string
+}
+
+// Get this publisher instance
+func Get() Publisher {
+	return instance
+}
+
+// GetConsumer instance
+func GetConsumer() Consumer {
+	return instance
+}
+
+func (q *queueImpl) Publish(ctx context.Context, msg Message) error {
+	return q.Send(ctx, q.events, msg)
+}
+
+func (q *queueImpl) Send(ctx context.Context, queue string, msg Message) error {
+	queueURL, err := q.getQueueURL(ctx, queue)
+	if err != nil {
+		return err
+	}
+
+	attributes := make(map[string]*sqs.MessageAttributeValue, len(msg.Attributes))
+	for name, value := range msg.Attributes {
+		attributes[name] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	_, err = sqs.New(repository.AWSSession).SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(queueURL),
+		MessageBody:       aws.String(msg.Body),
+		MessageAttributes: attributes,
+	})
+	return err
+}
+
+func (q *queueImpl) Receive(ctx context.Context, queue string, max int, wait, visibility time.Duration) ([]Received, error) {
+	queueURL, err := q.getQueueURL(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := sqs.New(repository.AWSSession).ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(queueURL),
+		MaxNumberOfMessages:   aws.Int64(int64(max)),
+		WaitTimeSeconds:       aws.Int64(int64(wait / time.Second)),
+		VisibilityTimeout:     aws.Int64(int64(visibility / time.Second)),
+		AttributeNames:        aws.StringSlice([]string{sqs.MessageSystemAttributeNameApproximateReceiveCount}),
+		MessageAttributeNames: aws.StringSlice([]string{"All"}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Received, 0, len(output.Messages))
+	for _, msg := range output.Messages {
+		attributes := make(map[string]string, len(msg.MessageAttributes))
+		for name, value := range msg.MessageAttributes {
+			attributes[name] = aws.StringValue(value.StringValue)
+		}
+		receiveCount, _ := strconv.Atoi(aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+
+		results = append(results, Received{
+			Message: Message{
+				Body:       aws.StringValue(msg.Body),
+				Attributes: attributes,
+			},
+			ID:            aws.StringValue(msg.MessageId),
+			ReceiptHandle: aws.StringValue(msg.ReceiptHandle),
+			ReceiveCount:  receiveCount,
+		})
+	}
+
+	return results, nil
+}
+
+func (q *queueImpl) ExtendVisibility(ctx context.Context, queue, receiptHandle string, visibility time.Duration) error {
+	queueURL, err := q.getQueueURL(ctx, queue)
+	if err != nil {
+		return err
+	}
+
+	_, err = sqs.New(repository.AWSSession).ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: aws.Int64(int64(visibility / time.Second)),
+	})
+	return err
+}
+
+func (q *queueImpl) Delete(ctx context.Context, queue, receiptHandle string) error {
+	queueURL, err := q.getQueueURL(ctx, queue)
+	if err != nil {
+		return err
+	}
+
+	_, err = sqs.New(repository.AWSSession).DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: aws.String(receiptHandle),
+	})
+	return err
+}
+
+// getQueueURL resolves the URL of a queue once it's found, failures are retried on the next call
+func (q *queueImpl) getQueueURL(ctx context.Context, queue string) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if queueURL, ok := q.queueURLs[queue]; ok {
+		return queueURL, nil
+	}
+
+	output, err := sqs.New(repository.AWSSession).GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{
+		QueueName: aws.String(queue),
+	})
+	if err != nil {
+		return "", err
+	}
+	q.queueURLs[queue] = aws.StringValue(output.QueueUrl)
+
+	return q.queueURLs[queue], nil
+}
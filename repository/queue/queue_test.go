@@ -0,0 +1,63 @@
+package queue_test
+
+import (
+	"context"
+	"go-boilerplate/repository"
+	"go-boilerplate/repository/queue"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+)
+
+var (
+	impl     = queue.Get()
+	consumer = queue.GetConsumer()
+)
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	body := gofakeit.UUID()
+
+	err := impl.Publish(ctx, queue.Message{
+		Body:       body,
+		Attributes: map[string]string{"type": "CommentCreated"},
+	})
+	if err != nil {
+		t.Errorf("unexpected error publishing message %s", err)
+		return
+	}
+
+	received, err := consumer.Receive(ctx, "comment-events", 10, time.Second, time.Minute)
+	if err != nil {
+		t.Errorf("unexpected error receiving messages %s", err)
+		return
+	}
+
+	for _, msg := range received {
+		err := consumer.Delete(ctx, "comment-events", msg.ReceiptHandle)
+		if err != nil {
+			t.Errorf("unexpected error deleting message %s", err)
+		}
+		if msg.Body != body {
+			continue
+		}
+		if tp := msg.Attributes["type"]; tp != "CommentCreated" {
+			t.Errorf("unexpected message type attribute %s", tp)
+		}
+		if msg.ReceiveCount != 1 {
+			t.Errorf("unexpected message receive count %d", msg.ReceiveCount)
+		}
+		return
+	}
+	t.Errorf("published message not received")
+}
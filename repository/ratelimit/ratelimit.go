@@ -0,0 +1,153 @@
+// Package ratelimit holds the state of rate limits, either kept in memory or in postgres so limits hold across replicas
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/domain/ratelimit"
+	"go-boilerplate/repository"
+	"sync"
+	"time"
+)
+
+const (
+	memoryStore   = "memory"
+	postgresStore = "postgres"
+)
+
+var (
+	storeName = common.Config.Get("rateLimitStore")
+	// storeTimeout of the statements of the postgres store, rate limiting shouldn't hold requests up
+	storeTimeout = time.Duration(common.Config.GetInt("rateLimitStoreTimeoutMilliseconds")) * time.Millisecond
+
+	memoryInstance   = newMemory()
+	postgresInstance = &postgresImpl{}
+)
+
+// Store to enable rate limit stores to be swapped and mocked
+type Store interface {
+	// Take a token of the bucket of the given key refilled following the given limit
+	Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error)
+	// Purge buckets not updated since the given time, they would be full again
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Get the store set by the rate limit store config
+func Get() Store {
+	return GetStore(storeName)
+}
+
+// GetStore of the given name, memory or postgres
+func GetStore(name string) Store {
+	if name == postgresStore {
+		return postgresInstance
+	}
+	if name != memoryStore {
+		common.Logger.Warnf("unknown rate limit store %s, using %s", name, memoryStore)
+	}
+	return memoryInstance
+}
+
+type memoryImpl struct {
+	mu      sync.Mutex
+	buckets map[string]ratelimit.Bucket
+}
+
+func newMemory() *memoryImpl {
+	return &memoryImpl{buckets: map[string]ratelimit.Bucket{}}
+}
+
+func (m *memoryImpl) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bucket, result := limit.Take(m.buckets[key], now)
+	m.buckets[key] = bucket
+
+	return result, nil
+}
+
+func (m *memoryImpl) Purge(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for key, bucket := range m.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(m.buckets, key)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// postgresImpl takes the token in a single upsert, so concurrent requests of a client are serialized by the row lock
+type postgresImpl struct{}
+
+// refill of the stored bucket up to the time of the request, following Limit.Refill
+const refill = `LEAST(?::double precision, rate_limit.tokens + GREATEST(0, EXTRACT(EPOCH FROM EXCLUDED.updated_at - rate_limit.updated_at)::double precision) * ?::double precision)`
+
+func (p *postgresImpl) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	requests := float64(limit.Requests)
+	rate := requests / limit.Period.Seconds()
+	first, _ := limit.Take(ratelimit.Bucket{}, now)
+
+	// the bucket is only updated when a token is taken, a denied request leaves it as it was
+	query, values, err := repository.Psq.Insert("rate_limit").
+		Columns("key", "tokens", "updated_at").
+		Values(key, first.Tokens, first.UpdatedAt).
+		Suffix("ON CONFLICT (key) DO UPDATE SET tokens = "+refill+" - 1, updated_at = EXCLUDED.updated_at", requests, rate).
+		Suffix("WHERE "+refill+" >= 1", requests, rate).
+		Suffix("RETURNING tokens").
+		ToSql()
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	tokens := 0.0
+	err = repository.DB.QueryRowContext(ctx, query, values...).Scan(&tokens)
+	if err == nil {
+		return limit.Result(tokens, true), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return ratelimit.Result{}, fmt.Errorf("error taking rate limit token %s: %w", key, err)
+	}
+
+	// denied, the bucket is read again only to tell the client when to retry
+	bucket := ratelimit.Bucket{}
+	query, values, err = repository.Psq.Select("tokens", "updated_at").
+		From("rate_limit").
+		Where("key = ?", key).
+		ToSql()
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	err = repository.DB.QueryRowContext(ctx, query, values...).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("error reading rate limit bucket %s: %w", key, err)
+	}
+
+	return limit.Result(limit.Refill(bucket, now), false), nil
+}
+
+func (p *postgresImpl) Purge(ctx context.Context, before time.Time) (int, error) {
+	query, values, err := repository.Psq.Delete("rate_limit").
+		Where("updated_at < ?", before).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := repository.DB.ExecContext(ctx, query, values...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
@@ -0,0 +1,85 @@
+package ratelimit_test
+
+import (
+	"context"
+	"go-boilerplate/domain/ratelimit"
+	"go-boilerplate/repository"
+	ratelimitRepository "go-boilerplate/repository/ratelimit"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestTake(t *testing.T) {
+	now := time.Now().Truncate(time.Microsecond)
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+
+	for _, store := range []string{"memory", "postgres"} {
+		t.Run(store, func(t *testing.T) {
+			impl := ratelimitRepository.GetStore(store)
+			key := gofakeit.UUID()
+			t.Cleanup(func() {
+				impl.Purge(context.Background(), now.Add(time.Hour))
+			})
+
+			expected := []ratelimit.Result{
+				{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second},
+				{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute},
+				{Limit: 2, Remaining: 0, Reset: time.Minute, RetryAfter: 30 * time.Second},
+			}
+			for i, e := range expected {
+				result, err := impl.Take(context.Background(), key, limit, now)
+				if err != nil {
+					t.Errorf("error taking token %d %s", i, err)
+					return
+				}
+				if diff := cmp.Diff(result, e); diff != "" {
+					t.Errorf("unexpected result of take %d %s", i, diff)
+				}
+			}
+
+			other, err := impl.Take(context.Background(), gofakeit.UUID(), limit, now)
+			if err != nil || !other.Allowed {
+				t.Errorf("unexpected result of another key %v %v", other, err)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Now()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	for _, store := range []string{"memory", "postgres"} {
+		t.Run(store, func(t *testing.T) {
+			impl := ratelimitRepository.GetStore(store)
+			key := gofakeit.UUID()
+			_, err := impl.Take(context.Background(), key, limit, now.Add(-2*time.Hour))
+			if err != nil {
+				t.Errorf("error taking token %s", err)
+				return
+			}
+
+			count, err := impl.Purge(context.Background(), now.Add(-time.Hour))
+			if err != nil || count < 1 {
+				t.Errorf("unexpected purge result %d %v", count, err)
+			}
+
+			result, err := impl.Take(context.Background(), key, limit, now)
+			if err != nil || !result.Allowed {
+				t.Errorf("unexpected result after purge %v %v", result, err)
+			}
+		})
+	}
+}
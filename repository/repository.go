@@ -2,26 +2,34 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"go-boilerplate/common"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	awstrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/aws-sdk-go/aws"
 )
 
 var (
 	// ErrNotFound is when no resource was found
 	ErrNotFound = errors.New("resource not found")
+	// ErrPreconditionFailed is when a resource changed since the version the request was based on
+	ErrPreconditionFailed = errors.New("resource version mismatch")
 
 	AWSSession *session.Session
 )
 
 // Setup prepares the entire layer to be used
 func Setup() error {
-	setupHTTP()
+	err := setupHTTP()
+	if err != nil {
+		return err
+	}
 
-	err := setupAWSSession()
+	err = setupAWSSession()
 	if err != nil {
 		return err
 	}
@@ -34,13 +42,29 @@ func Setup() error {
 	return nil
 }
 
+// Close releases the entire layer resources, database pool first and then http client connections
+func Close() error {
+	err := closeDB()
+	closeHTTP()
+
+	return err
+}
+
 // HealthcheckResponse response of healthcheck process
 type HealthcheckResponse struct {
-	DB   string
+	DB string
+	// SQS doesn't make the application unhealthy, events wait in the outbox until it's reachable again
+	SQS  string
 	HTTP string
+	// Circuits of the hosts whose circuit breaker isn't closed, they don't make the application unhealthy
+	Circuits map[string]string `json:",omitempty"`
 }
 
-const ok = "OK"
+const (
+	ok = "OK"
+
+	sqsHealthcheckTimeout = 2 * time.Second
+)
 
 // Healthy checks if all dependencies are healthy
 func (h HealthcheckResponse) Healthy() bool {
@@ -51,6 +75,8 @@ func (h HealthcheckResponse) Healthy() bool {
 func Healthcheck() HealthcheckResponse {
 	_, dbErr := dbHealthcheck()
 
+	sqsErr := sqsHealthcheck()
+
 	httpErr := httpHealthcheck()
 
 	DB := ok
@@ -58,21 +84,34 @@ func Healthcheck() HealthcheckResponse {
 		DB = dbErr.Error()
 	}
 
+	SQS := ok
+	if sqsErr != nil {
+		SQS = sqsErr.Error()
+	}
+
 	HTTP := ok
 	if httpErr != nil {
 		HTTP = httpErr.Error()
 	}
 
 	return HealthcheckResponse{
-		DB:   DB,
-		HTTP: HTTP,
+		DB:       DB,
+		SQS:      SQS,
+		HTTP:     HTTP,
+		Circuits: circuitStatus(),
 	}
 }
 
 func setupAWSSession() error {
-	sess, err := session.NewSession(&aws.Config{
+	cfg := &aws.Config{
 		Region: aws.String(common.Config.Get("awsRegion")),
-	})
+	}
+	if endpoint := common.Config.Get("awsEndpoint"); endpoint != "" {
+		cfg.Endpoint = aws.String(endpoint)
+		cfg.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSession(cfg)
 	if err != nil {
 		return err
 	}
@@ -81,3 +120,13 @@ func setupAWSSession() error {
 
 	return nil
 }
+
+func sqsHealthcheck() error {
+	ctx, cancel := context.WithTimeout(context.Background(), sqsHealthcheckTimeout)
+	defer cancel()
+
+	_, err := sqs.New(AWSSession).GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{
+		QueueName: aws.String(common.Config.Get("commentEventsQueue")),
+	})
+	return err
+}
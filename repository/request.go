@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/common/metrics"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrResponseTooLarge is when a response body exceeds the max size of its request
+	ErrResponseTooLarge = errors.New("response too large")
+
+	httpMaxResponseBytes = int64(common.Config.GetInt("httpMaxResponseBytes"))
+
+	httpClientRequests = metrics.Factory().NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_requests_total",
+		Help: "Outbound http requests attempted by host, retries included",
+	}, []string{"host"})
+	httpClientErrors = metrics.Factory().NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_errors_total",
+		Help: "Outbound http requests failed by host, either by transport errors or 429 and 5xx responses",
+	}, []string{"host"})
+	httpClientRetries = metrics.Factory().NewCounterVec(prometheus.CounterOpts{
+		Name: "http_client_retries_total",
+		Help: "Outbound http requests retried by host",
+	}, []string{"host"})
+)
+
+// HTTPError of a response with a non successful status, its body is kept so it can be decoded by ErrorBody
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("error executing %s %s - %d - %s", e.Method, e.URL, e.StatusCode, string(e.Body))
+}
+
+// Unwrap the repository error matching the status, so errors.Is(err, ErrNotFound) works on http errors
+func (e *HTTPError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorizedResource
+	case http.StatusUnprocessableEntity:
+		return ErrUnprocessableEntityResource
+	}
+	return nil
+}
+
+// ErrorBody decodes the json body of the http error wrapped by the given error into E
+func ErrorBody[E any](err error) (E, bool) {
+	var body E
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || len(httpErr.Body) == 0 {
+		return body, false
+	}
+
+	if json.Unmarshal(httpErr.Body, &body) != nil {
+		return body, false
+	}
+	return body, true
+}
+
+// RequestBuilder of an http request whose json response body is decoded into T
+type RequestBuilder[T any] struct {
+	ctx             context.Context
+	method          string
+	url             string
+	query           url.Values
+	header          http.Header
+	body            []byte
+	timeout         time.Duration
+	retry           *bool
+	maxResponseSize int64
+	err             error
+}
+
+// Request starts building a GET request bound to the given context, executed with the default http instance.
+// Requests are guarded by the circuit breaker of their host and idempotent ones are retried following its policy
+func Request[T any](ctx context.Context) *RequestBuilder[T] {
+	return &RequestBuilder[T]{
+		ctx:             ctx,
+		method:          http.MethodGet,
+		query:           url.Values{},
+		header:          http.Header{},
+		timeout:         httpTimeoutDuration,
+		maxResponseSize: httpMaxResponseBytes,
+	}
+}
+
+// Method of the request, GET by default
+func (b *RequestBuilder[T]) Method(method string) *RequestBuilder[T] {
+	b.method = method
+	return b
+}
+
+// URL of the request, its query is merged with the one built by Query
+func (b *RequestBuilder[T]) URL(url string) *RequestBuilder[T] {
+	b.url = url
+	return b
+}
+
+// Query adds the given values to the query of the request
+func (b *RequestBuilder[T]) Query(key string, values ...string) *RequestBuilder[T] {
+	for _, value := range values {
+		b.query.Add(key, value)
+	}
+	return b
+}
+
+// Header sets the given header of the request
+func (b *RequestBuilder[T]) Header(key, value string) *RequestBuilder[T] {
+	b.header.Set(key, value)
+	return b
+}
+
+// Headers adds all the given headers to the request
+func (b *RequestBuilder[T]) Headers(header http.Header) *RequestBuilder[T] {
+	for key, values := range header {
+		for _, value := range values {
+			b.header.Add(key, value)
+		}
+	}
+	return b
+}
+
+// JSONBody encodes the given value as the json body of the request
+func (b *RequestBuilder[T]) JSONBody(value interface{}) *RequestBuilder[T] {
+	body, err := json.Marshal(value)
+	if err != nil {
+		b.err = fmt.Errorf("error encoding request body: %w", err)
+		return b
+	}
+	b.body = body
+	b.header.Set("Content-Type", "application/json")
+	return b
+}
+
+// Body of the request, read once and buffered so it can be replayed on retries
+func (b *RequestBuilder[T]) Body(body io.Reader) *RequestBuilder[T] {
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		b.err = fmt.Errorf("error reading request body: %w", err)
+		return b
+	}
+	b.body = content
+	return b
+}
+
+// Timeout of each attempt of the request, the http timeout by default
+func (b *RequestBuilder[T]) Timeout(timeout time.Duration) *RequestBuilder[T] {
+	b.timeout = timeout
+	return b
+}
+
+// Retry enables or disables retries, by default only safe requests are retried as the http helpers do
+func (b *RequestBuilder[T]) Retry(retry bool) *RequestBuilder[T] {
+	b.retry = &retry
+	return b
+}
+
+// MaxResponseSize in bytes of the response body, the http max response bytes by default
+func (b *RequestBuilder[T]) MaxResponseSize(size int64) *RequestBuilder[T] {
+	b.maxResponseSize = size
+	return b
+}
+
+// Do executes the request decoding its response body, an empty body results in the zero value of T.
+// Non successful responses result in an *HTTPError
+func (b *RequestBuilder[T]) Do() (T, error) {
+	var result T
+	content, err := b.execute()
+	if err != nil {
+		return result, err
+	}
+
+	if len(content) == 0 {
+		return result, nil
+	}
+	err = json.Unmarshal(content, &result)
+	if err != nil {
+		return result, fmt.Errorf("error decoding response of %s %s: %w", b.method, b.url, err)
+	}
+
+	return result, nil
+}
+
+// execute the request returning its response body, retrying it following the policy of its host
+func (b *RequestBuilder[T]) execute() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	requestURL, err := url.Parse(b.url)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.query) > 0 {
+		query := requestURL.Query()
+		for key, values := range b.query {
+			query[key] = append(query[key], values...)
+		}
+		requestURL.RawQuery = query.Encode()
+	}
+	target := requestURL.String()
+
+	policy := httpPolicy(requestURL.Host)
+	breaker := hostBreaker(requestURL.Host)
+	for attempt := 1; ; attempt++ {
+		response, content, err := b.attempt(breaker, target)
+		retryable := (err != nil && !errors.Is(err, ErrCircuitOpen) && b.ctx.Err() == nil) ||
+			(err == nil && retryableStatus(response.StatusCode))
+		if !retryable || !b.retryEnabled() || attempt > policy.MaxRetries {
+			if err != nil {
+				return nil, err
+			}
+			return b.parse(target, response, content)
+		}
+
+		wait := policy.backoff(attempt, response)
+		if err == nil {
+			err = fmt.Errorf("status %d", response.StatusCode)
+		}
+		httpClientRetries.WithLabelValues(requestURL.Host).Inc()
+		common.LoggerFrom(b.ctx).Warnf("retrying, method: %s, url: %s, attempt: %d, wait: %v, timeout: %v, err: %v", b.method, target, attempt, wait, b.timeout, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-b.ctx.Done():
+			timer.Stop()
+			return nil, b.ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt the request once if the circuit of its host allows it, recording the outcome in the circuit
+func (b *RequestBuilder[T]) attempt(breaker *circuitBreaker, target string) (*http.Response, []byte, error) {
+	err := breaker.allow(time.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error executing %s %s: %w", b.method, target, err)
+	}
+
+	ctx, cancel := context.WithTimeout(b.ctx, b.timeout)
+	defer cancel()
+	var body io.Reader
+	if b.body != nil {
+		body = bytes.NewReader(b.body)
+	}
+	request, err := http.NewRequestWithContext(ctx, b.method, target, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header = b.header.Clone()
+	if requestID := common.RequestID(b.ctx); requestID != "" && request.Header.Get("X-Request-ID") == "" {
+		request.Header.Set("X-Request-ID", requestID)
+	}
+
+	host := request.URL.Host
+	httpClientRequests.WithLabelValues(host).Inc()
+	response, err := HTTP.Do(request)
+	defer CloseBody(response)
+	if err != nil {
+		httpClientErrors.WithLabelValues(host).Inc()
+		b.recordFailure(breaker)
+		return nil, nil, err
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(response.Body, b.maxResponseSize+1))
+	if err != nil {
+		httpClientErrors.WithLabelValues(host).Inc()
+		b.recordFailure(breaker)
+		return nil, nil, err
+	}
+
+	if response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests {
+		httpClientErrors.WithLabelValues(host).Inc()
+		breaker.failure(time.Now())
+	} else {
+		breaker.success()
+	}
+
+	if int64(len(content)) > b.maxResponseSize {
+		return nil, nil, fmt.Errorf("error executing %s %s - %d bytes limit: %w", b.method, target, b.maxResponseSize, ErrResponseTooLarge)
+	}
+
+	return response, content, nil
+}
+
+// recordFailure of an attempt in the circuit, unless the caller canceled it, releasing the probe of a half open circuit then
+func (b *RequestBuilder[T]) recordFailure(breaker *circuitBreaker) {
+	if b.ctx.Err() != nil {
+		breaker.release()
+		return
+	}
+	breaker.failure(time.Now())
+}
+
+func (b *RequestBuilder[T]) parse(target string, response *http.Response, content []byte) ([]byte, error) {
+	if response.StatusCode >= http.StatusMultipleChoices {
+		err := &HTTPError{Method: b.method, URL: target, StatusCode: response.StatusCode, Body: content}
+		if errors.Unwrap(err) != nil {
+			common.LoggerFrom(b.ctx).Errorf("%s", err)
+		}
+		return nil, err
+	}
+
+	if common.Config.Get("httpResponseDebug") == "true" {
+		common.LoggerFrom(b.ctx).Debugf("response from %s %s - %s", b.method, target, string(content))
+	}
+
+	return content, nil
+}
+
+func (b *RequestBuilder[T]) retryEnabled() bool {
+	if b.retry != nil {
+		return *b.retry
+	}
+
+	switch b.method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
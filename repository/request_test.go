@@ -0,0 +1,169 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-boilerplate/common/metrics"
+	"go-boilerplate/repository"
+	"go-boilerplate/test"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type apiError struct {
+	Code string `json:"code"`
+}
+
+func TestRequest(t *testing.T) {
+	failedEcho := false
+	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/anything":
+			w.Write([]byte(`{"id":1,"desc":"anything"}`))
+		case "/search":
+			w.Write([]byte(`{"id":2,"desc":"` + r.URL.RawQuery + `"}`))
+		case "/echo":
+			if !failedEcho {
+				failedEcho = true
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(body)
+		case "/invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"INVALID_ID"}`))
+		case "/large":
+			w.Write([]byte(`{"id":3,"desc":"larger than the limit"}`))
+		}
+	})
+
+	testCases := []struct {
+		name          string
+		request       *repository.RequestBuilder[anything]
+		expected      anything
+		expectedError string
+	}{
+		{
+			name:     "typed response decoded",
+			request:  repository.Request[anything](context.Background()).URL("http://127.0.0.1:8001/anything"),
+			expected: anything{ID: 1, Desc: "anything"},
+		},
+		{
+			name: "query built into the url",
+			request: repository.Request[anything](context.Background()).
+				URL("http://127.0.0.1:8001/search?page=1").
+				Query("q", "a b").
+				Query("tag", "x", "y"),
+			expected: anything{ID: 2, Desc: "page=1&q=a+b&tag=x&tag=y"},
+		},
+		{
+			name: "json body replayed on retry",
+			request: repository.Request[anything](context.Background()).
+				Method(http.MethodPut).
+				URL("http://127.0.0.1:8001/echo").
+				JSONBody(anything{ID: 4, Desc: "echo"}).
+				Retry(true),
+			expected: anything{ID: 4, Desc: "echo"},
+		},
+		{
+			name:          "error response",
+			request:       repository.Request[anything](context.Background()).URL("http://127.0.0.1:8001/invalid"),
+			expectedError: `error executing GET http://127.0.0.1:8001/invalid - 400 - {"code":"INVALID_ID"}`,
+		},
+		{
+			name: "response larger than the limit",
+			request: repository.Request[anything](context.Background()).
+				URL("http://127.0.0.1:8001/large").
+				MaxResponseSize(10),
+			expectedError: "error executing GET http://127.0.0.1:8001/large - 10 bytes limit: response too large",
+		},
+		{
+			name: "invalid json body",
+			request: repository.Request[anything](context.Background()).
+				Method(http.MethodPost).
+				URL("http://127.0.0.1:8001/anything").
+				JSONBody(func() {}),
+			expectedError: "error encoding request body: json: unsupported type: func()",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.request.Do()
+			if tc.expectedError != "" && (err == nil || tc.expectedError != err.Error()) {
+				t.Errorf("unexpected error executing request %v", err)
+				return
+			}
+			if tc.expectedError == "" && err != nil {
+				t.Errorf("unexpected error executing request %s", err)
+				return
+			}
+			if diff := cmp.Diff(tc.expected, result); diff != "" {
+				t.Errorf("unexpected result of request %s", diff)
+			}
+		})
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	repository.Request[anything](context.Background()).URL("http://127.0.0.1:8001/unavailable").Do()
+
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Errorf("error gathering metrics %s", err)
+		return
+	}
+	found := map[string]bool{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "host" && label.GetValue() == "127.0.0.1:8001" && metric.GetCounter().GetValue() > 0 {
+					found[family.GetName()] = true
+				}
+			}
+		}
+	}
+	for _, name := range []string{"http_client_requests_total", "http_client_errors_total", "http_client_retries_total"} {
+		if !found[name] {
+			t.Errorf("metric %s not counted", name)
+		}
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"NOT_FOUND"}`))
+	})
+
+	_, err := repository.Request[json.RawMessage](context.Background()).URL("http://127.0.0.1:8001/missing").Do()
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("unexpected error %v", err)
+	}
+	body, ok := repository.ErrorBody[apiError](err)
+	if !ok || body.Code != "NOT_FOUND" {
+		t.Errorf("unexpected error body %t %v", ok, body)
+	}
+}
+
+func TestRequestContextCanceled(t *testing.T) {
+	test.MockHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := repository.Request[anything](ctx).URL("http://127.0.0.1:8001/slow").Do()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
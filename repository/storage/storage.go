@@ -0,0 +1,89 @@
+// Package storage holds access logic of files kept in S3
+package storage
+
+import (
+	"context"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/repository"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxDeleteKeys is the S3 limit of objects deleted per request
+const maxDeleteKeys = 1000
+
+var (
+	instance = &storageImpl{
+		bucket: common.Config.Get("commentAttachmentsBucket"),
+	}
+)
+
+// Storage to enable this repository to be mocked
+type Storage interface {
+	// PresignPut returns a URL to upload an object with the given content type and size until it expires
+	PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error)
+	// PresignGet returns a URL to download an object as the given file name until it expires
+	PresignGet(ctx context.Context, key, fileName string, expires time.Duration) (string, error)
+	// Delete the objects of the given keys
+	Delete(ctx context.Context, keys []string) error
+}
+
+type storageImpl struct {
+	bucket string
+}
+
+// Get this storage instance
+func Get() Storage {
+	return instance
+}
+
+func (s *storageImpl) PresignPut(ctx context.Context, key, contentType string, size int64, expires time.Duration) (string, error) {
+	req, _ := s3.New(repository.AWSSession).PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	})
+	req.SetContext(ctx)
+
+	return req.Presign(expires)
+}
+
+func (s *storageImpl) PresignGet(ctx context.Context, key, fileName string, expires time.Duration) (string, error) {
+	req, _ := s3.New(repository.AWSSession).GetObjectRequest(&s3.GetObjectInput{
+		Bucket:                     aws.String(s.bucket),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(fmt.Sprintf("attachment; filename=%q", fileName)),
+	})
+	req.SetContext(ctx)
+
+	return req.Presign(expires)
+}
+
+func (s *storageImpl) Delete(ctx context.Context, keys []string) error {
+	client := s3.New(repository.AWSSession)
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		end := start + maxDeleteKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		objects := make([]*s3.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		_, err := client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"go-boilerplate/repository"
+	"go-boilerplate/repository/storage"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+)
+
+var impl = storage.Get()
+
+func TestMain(m *testing.M) {
+	err := repository.Setup()
+	if err != nil {
+		os.Exit(-1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestPresign(t *testing.T) {
+	ctx := context.Background()
+	key := "comment/test/" + gofakeit.UUID()
+	content := []byte(gofakeit.HackerPhrase())
+	t.Cleanup(func() {
+		if err := impl.Delete(ctx, []string{key}); err != nil {
+			t.Errorf("error cleaning up storage test data %s", err)
+		}
+	})
+
+	uploadURL, err := impl.PresignPut(ctx, key, "application/pdf", int64(len(content)), time.Minute)
+	if err != nil {
+		t.Errorf("unexpected error presigning upload %s", err)
+		return
+	}
+	req, _ := http.NewRequest(http.MethodPut, uploadURL, bytes.NewReader(content))
+	req.Header.Set("Content-Type", "application/pdf")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected upload response %v %v", resp, err)
+		return
+	}
+	resp.Body.Close()
+
+	downloadURL, err := impl.PresignGet(ctx, key, "contrato.pdf", time.Minute)
+	if err != nil {
+		t.Errorf("unexpected error presigning download %s", err)
+		return
+	}
+	resp, err = http.Get(downloadURL)
+	if err != nil {
+		t.Errorf("unexpected download error %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	downloaded, _ := io.ReadAll(resp.Body)
+	if !bytes.Equal(downloaded, content) {
+		t.Errorf("unexpected downloaded content %s", downloaded)
+	}
+	if disposition := resp.Header.Get("Content-Disposition"); disposition != `attachment; filename="contrato.pdf"` {
+		t.Errorf("unexpected content disposition %s", disposition)
+	}
+}
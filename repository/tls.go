@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"go-boilerplate/common"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// ClientCert of mutual TLS, PEM encoded files
+type ClientCert struct {
+	CertFile string
+	KeyFile  string
+}
+
+// TLSOptions of the outbound requests, certificates are always verified unless their host is allowlisted
+type TLSOptions struct {
+	// CAFile with a PEM bundle of authorities trusted besides the system ones
+	CAFile string
+	// ClientCerts presented to each host (eg my-account-api.vivareal.com.br)
+	ClientCerts map[string]ClientCert
+	// InsecureHosts whose certificates aren't verified, meant for dev only
+	InsecureHosts []string
+}
+
+// tlsSettings loaded from the TLS options, shared by all the connections of the http transport
+type tlsSettings struct {
+	rootCAs       *x509.CertPool
+	clientCerts   map[string]tls.Certificate
+	insecureHosts map[string]bool
+}
+
+var currentTLS atomic.Value
+
+// ConfigureTLS of the outbound requests, the idle connections are closed so new ones follow the given options
+func ConfigureTLS(options TLSOptions) error {
+	settings, err := loadTLSSettings(options)
+	if err != nil {
+		return err
+	}
+
+	currentTLS.Store(settings)
+	customHTTPTransport.CloseIdleConnections()
+
+	return nil
+}
+
+func tlsOptionsFromConfig() (TLSOptions, error) {
+	options := TLSOptions{
+		CAFile:      common.Config.Get("httpTlsCaFile"),
+		ClientCerts: map[string]ClientCert{},
+	}
+
+	// comma separated hosts (eg localhost,wiremock)
+	for _, host := range strings.Split(common.Config.Get("httpTlsInsecureHosts"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			options.InsecureHosts = append(options.InsecureHosts, host)
+		}
+	}
+
+	// comma separated client certs by host (eg api.example.com=/certs/client.pem:/certs/client-key.pem)
+	for _, entry := range strings.Split(common.Config.Get("httpTlsClientCerts"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		host, files, ok := strings.Cut(entry, "=")
+		certFile, keyFile, filesOk := strings.Cut(files, ":")
+		if !ok || !filesOk || host == "" {
+			return TLSOptions{}, fmt.Errorf("invalid tls client cert %s, expected host=certFile:keyFile", entry)
+		}
+		options.ClientCerts[host] = ClientCert{CertFile: certFile, KeyFile: keyFile}
+	}
+
+	return options, nil
+}
+
+func loadTLSSettings(options TLSOptions) (*tlsSettings, error) {
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if options.CAFile != "" {
+		bundle, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading tls ca file: %w", err)
+		}
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("error loading tls ca file %s: no certificates found", options.CAFile)
+		}
+	}
+
+	clientCerts := map[string]tls.Certificate{}
+	for host, cert := range options.ClientCerts {
+		clientCert, err := tls.LoadX509KeyPair(cert.CertFile, cert.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading tls client cert of %s: %w", host, err)
+		}
+		clientCerts[host] = clientCert
+	}
+
+	insecureHosts := map[string]bool{}
+	for _, host := range options.InsecureHosts {
+		common.Logger.Warnf("tls verification disabled for %s", host)
+		insecureHosts[host] = true
+	}
+
+	return &tlsSettings{
+		rootCAs:       rootCAs,
+		clientCerts:   clientCerts,
+		insecureHosts: insecureHosts,
+	}, nil
+}
+
+// config of the TLS connections to the given host
+func (s *tlsSettings) config(host string) *tls.Config {
+	cfg := &tls.Config{
+		ServerName:         host,
+		RootCAs:            s.rootCAs,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.insecureHosts[host],
+	}
+	if cert, ok := s.clientCerts[host]; ok {
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg
+}
+
+// tlsDialer wraps the given dialer with a TLS handshake following the current settings of the destination host
+func tlsDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		settings, ok := currentTLS.Load().(*tlsSettings)
+		if !ok {
+			return nil, fmt.Errorf("error dialing %s: tls not configured", addr)
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, settings.config(host))
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+}
@@ -0,0 +1,123 @@
+package repository_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"go-boilerplate/repository"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTLSFiles of the given test server, its own certificate is used as the CA and the client cert
+func writeTLSFiles(t *testing.T, server *httptest.Server) (caFile string, cert repository.ClientCert) {
+	dir := t.TempDir()
+	caFile = filepath.Join(dir, "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	key, err := x509.MarshalPKCS8PrivateKey(server.TLS.Certificates[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("error encoding test server key %s", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+
+	cert = repository.ClientCert{
+		CertFile: filepath.Join(dir, "client.pem"),
+		KeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+	for file, content := range map[string][]byte{caFile: certPEM, cert.CertFile: certPEM, cert.KeyFile: keyPEM} {
+		if err := os.WriteFile(file, content, 0600); err != nil {
+			t.Fatalf("error writing %s %s", file, err)
+		}
+	}
+
+	return caFile, cert
+}
+
+func TestConfigureTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mtls" && len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":1,"desc":"anything"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+	t.Cleanup(func() {
+		repository.ConfigureTLS(repository.TLSOptions{})
+	})
+	caFile, cert := writeTLSFiles(t, server)
+
+	testCases := []struct {
+		name          string
+		options       repository.TLSOptions
+		path          string
+		expectedError string
+	}{
+		{
+			name:          "unknown authority rejected by default",
+			options:       repository.TLSOptions{},
+			path:          "/anything",
+			expectedError: "certificate signed by unknown authority",
+		},
+		{
+			name:    "authority trusted from the ca file",
+			options: repository.TLSOptions{CAFile: caFile},
+			path:    "/anything",
+		},
+		{
+			name:    "insecure host allowlisted",
+			options: repository.TLSOptions{InsecureHosts: []string{"127.0.0.1"}},
+			path:    "/anything",
+		},
+		{
+			name:          "client cert missing",
+			options:       repository.TLSOptions{CAFile: caFile},
+			path:          "/mtls",
+			expectedError: "- 401 -",
+		},
+		{
+			name: "client cert of the host",
+			options: repository.TLSOptions{
+				CAFile:      caFile,
+				ClientCerts: map[string]repository.ClientCert{"127.0.0.1": cert},
+			},
+			path: "/mtls",
+		},
+		{
+			name: "client cert of another host not presented",
+			options: repository.TLSOptions{
+				CAFile:      caFile,
+				ClientCerts: map[string]repository.ClientCert{"example.com": cert},
+			},
+			path:          "/mtls",
+			expectedError: "- 401 -",
+		},
+		{
+			name:          "invalid ca file",
+			options:       repository.TLSOptions{CAFile: cert.KeyFile},
+			expectedError: "no certificates found",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := repository.ConfigureTLS(tc.options)
+			if err == nil {
+				_, err = repository.Request[anything](context.Background()).URL(server.URL + tc.path).Retry(false).Do()
+			}
+			if tc.expectedError != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedError)) {
+				t.Errorf("unexpected error %v", err)
+				return
+			}
+			if tc.expectedError == "" && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package fixtures
 
 import (
+	"go-boilerplate/domain"
 	"go-boilerplate/domain/comment"
 
 	"github.com/brianvoe/gofakeit/v5"
@@ -15,5 +16,11 @@ func AnyComment() comment.Comment {
 		AccountID:    gofakeit.UUID(),
 		AdvertiserID: gofakeit.UUID(),
 		ListingID:    gofakeit.Numerify("########"),
+		Owner: comment.Owner{
+			Name:      gofakeit.Name(),
+			Email:     gofakeit.Email(),
+			AccountID: gofakeit.UUID(),
+			Role:      domain.AdvertiserRole,
+		},
 	}
 }
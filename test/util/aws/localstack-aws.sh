@@ -4,4 +4,7 @@ echo '{}' > dev-configs.json
 awslocal s3 cp dev-configs.json s3://advertiser-config/
 awslocal s3 mb s3://datalake-bucket
 awslocal s3 mb s3://email-attachments-bucket
+awslocal sqs create-queue --queue-name comment-events
+awslocal sqs create-queue --queue-name comment-import
+awslocal sqs create-queue --queue-name comment-import-dlq
 set +x
\ No newline at end of file
@@ -0,0 +1,38 @@
+// Package v1 holds comment v1 queue message handlers
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/domain/comment"
+	commentFacade "go-boilerplate/facade/comment"
+	"go-boilerplate/repository"
+	"go-boilerplate/repository/queue"
+	"go-boilerplate/worker/consumer"
+)
+
+// CommentImportHandler imports a comment pushed by another CRM system, messages of comments already imported are skipped.
+// Malformed, invalid or unprocessable messages are poison
+func CommentImportHandler(ctx context.Context, msg queue.Received) error {
+	imp := comment.Import{}
+	if err := json.Unmarshal([]byte(msg.Body), &imp); err != nil {
+		return fmt.Errorf("%w: %s", consumer.ErrPoison, err)
+	}
+	if err := imp.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", consumer.ErrPoison, err)
+	}
+
+	ID, err := commentFacade.Get().Import(ctx, imp)
+	if errors.Is(err, repository.ErrUnprocessableEntityResource) {
+		return fmt.Errorf("%w: %s", consumer.ErrPoison, err)
+	}
+	if err != nil {
+		return err
+	}
+	common.Logger.Infof("comment %s of %s imported as %d", imp.ExternalID, imp.Source, ID)
+
+	return nil
+}
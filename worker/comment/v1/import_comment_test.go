@@ -0,0 +1,42 @@
+package v1_test
+
+import (
+	"context"
+	"go-boilerplate/repository/queue"
+	"go-boilerplate/test"
+	v1 "go-boilerplate/worker/comment/v1"
+	"go-boilerplate/worker/consumer"
+	"testing"
+)
+
+func TestCommentImportHandler(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		expectedError error
+	}{
+		{
+			name:          "malformed message",
+			body:          `{"source":`,
+			expectedError: consumer.ErrPoison,
+		},
+		{
+			name:          "invalid import",
+			body:          `{"source":"crm","comment":{"type":"LEAD"}}`,
+			expectedError: consumer.ErrPoison,
+		},
+		{
+			name:          "unknown comment type",
+			body:          `{"source":"crm","externalId":"1","comment":{"type":"ANY"}}`,
+			expectedError: consumer.ErrPoison,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v1.CommentImportHandler(context.Background(), queue.Received{
+				Message: queue.Message{Body: tc.body},
+			})
+			test.AssertErrorType(t, err, tc.expectedError)
+		})
+	}
+}
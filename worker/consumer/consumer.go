@@ -0,0 +1,241 @@
+// Package consumer long polls queues dispatching their messages to handlers
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/repository/queue"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// maxReceiveMessages is the SQS limit of messages received per request
+const maxReceiveMessages = 10
+
+// receiveErrorDelay before polling again after a receive failure
+const receiveErrorDelay = time.Second
+
+// ErrPoison is when a message can never be processed, handlers wrap it so the message is routed to the dead letter queue
+var ErrPoison = errors.New("poison message")
+
+// Handler processes a received message, the message is deleted when it succeeds and received again
+// after its visibility timeout when it fails, unless the error wraps ErrPoison
+type Handler func(ctx context.Context, msg queue.Received) error
+
+// Consumer of a queue, it handles up to its concurrency messages at the same time
+type Consumer struct {
+	Queue string
+	// DeadLetterQueue receives poison messages and the ones received more than the maximum receives
+	DeadLetterQueue string
+	Handler         Handler
+	Messages        queue.Consumer
+	Concurrency     int
+	MaxReceives     int
+	// Wait is how long each poll waits for messages to arrive
+	Wait time.Duration
+	// Visibility timeout of received messages, extended while they're being handled
+	Visibility time.Duration
+	// ShutdownTimeout to wait for in-flight messages before cancelling their handlers
+	ShutdownTimeout time.Duration
+}
+
+// Run polls the queue until the given context is done, then waits for in-flight messages within the shutdown timeout.
+// Handlers don't share the given context so they aren't interrupted by the shutdown
+func (c *Consumer) Run(ctx context.Context) error {
+	handlerCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	slots := make(chan struct{}, c.Concurrency)
+	wg := &sync.WaitGroup{}
+	for {
+		free, ok := c.acquire(ctx, slots)
+		if !ok {
+			break
+		}
+
+		msgs, err := c.Messages.Receive(ctx, c.Queue, free, c.Wait, c.Visibility)
+		for i := len(msgs); i < free; i++ {
+			<-slots
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			common.HandleError(fmt.Sprintf("error receiving messages from queue %s", c.Queue), err)
+			c.sleep(ctx, receiveErrorDelay)
+			continue
+		}
+
+		for _, msg := range msgs {
+			wg.Add(1)
+			go func(msg queue.Received) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				c.process(handlerCtx, msg)
+			}(msg)
+		}
+	}
+
+	return c.shutdown(wg, cancelHandlers)
+}
+
+// acquire waits for a free slot and then takes as many free slots as a single receive can fill
+func (c *Consumer) acquire(ctx context.Context, slots chan struct{}) (int, bool) {
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return 0, false
+	}
+
+	acquired := 1
+	for acquired < maxReceiveMessages {
+		select {
+		case slots <- struct{}{}:
+			acquired++
+		default:
+			return acquired, true
+		}
+	}
+
+	return acquired, true
+}
+
+func (c *Consumer) sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func (c *Consumer) process(ctx context.Context, msg queue.Received) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "sqs.consume",
+		tracer.ServiceName("go-boilerplate-worker"),
+		tracer.ResourceName(c.Queue),
+		tracer.SpanType(ext.SpanTypeMessageConsumer),
+		tracer.Tag(ext.MessagingSystem, "amazonsqs"),
+		tracer.Tag("message.id", msg.ID),
+		tracer.Tag("message.receive_count", msg.ReceiveCount),
+	)
+	var err error
+	defer func() {
+		span.Finish(tracer.WithError(err))
+	}()
+
+	if c.MaxReceives > 0 && msg.ReceiveCount > c.MaxReceives {
+		err = c.deadLetter(ctx, msg, fmt.Errorf("%w: received %d times", ErrPoison, msg.ReceiveCount))
+		return
+	}
+
+	stop := c.keepInvisible(ctx, msg)
+	err = c.handle(ctx, msg)
+	stop()
+
+	switch {
+	case errors.Is(err, ErrPoison):
+		err = c.deadLetter(ctx, msg, err)
+	case err != nil:
+		common.HandleError(fmt.Sprintf("error handling message %s from queue %s", msg.ID, c.Queue), err)
+	default:
+		err = c.Messages.Delete(ctx, c.Queue, msg.ReceiptHandle)
+		if err != nil {
+			common.HandleError(fmt.Sprintf("error deleting message %s from queue %s", msg.ID, c.Queue), err)
+		}
+	}
+}
+
+// handle the given message recovering handler panics as errors, so the message is received again
+func (c *Consumer) handle(ctx context.Context, msg queue.Received) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic handling message: %v", p)
+		}
+	}()
+
+	return c.Handler(ctx, msg)
+}
+
+// keepInvisible extends the visibility timeout of the given message until the returned func is called
+func (c *Consumer) keepInvisible(ctx context.Context, msg queue.Received) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(c.Visibility / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := c.Messages.ExtendVisibility(ctx, c.Queue, msg.ReceiptHandle, c.Visibility)
+				if err != nil {
+					common.HandleError(fmt.Sprintf("error extending visibility of message %s from queue %s", msg.ID, c.Queue), err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// deadLetter sends the given message to the dead letter queue with the reason it failed and deletes it from the queue
+func (c *Consumer) deadLetter(ctx context.Context, msg queue.Received, reason error) error {
+	common.Logger.Warnf("routing message %s from queue %s to dead letter queue %s: %s", msg.ID, c.Queue, c.DeadLetterQueue, reason)
+
+	attributes := make(map[string]string, len(msg.Attributes)+1)
+	for name, value := range msg.Attributes {
+		attributes[name] = value
+	}
+	attributes["error"] = reason.Error()
+
+	err := c.Messages.Send(ctx, c.DeadLetterQueue, queue.Message{
+		Body:       msg.Body,
+		Attributes: attributes,
+	})
+	if err != nil {
+		common.HandleError(fmt.Sprintf("error sending message %s to dead letter queue %s", msg.ID, c.DeadLetterQueue), err)
+		return err
+	}
+
+	err = c.Messages.Delete(ctx, c.Queue, msg.ReceiptHandle)
+	if err != nil {
+		common.HandleError(fmt.Sprintf("error deleting message %s from queue %s", msg.ID, c.Queue), err)
+	}
+
+	return err
+}
+
+// shutdown waits for in-flight messages within the shutdown timeout, then cancels their handlers
+func (c *Consumer) shutdown(wg *sync.WaitGroup, cancelHandlers context.CancelFunc) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(c.ShutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		cancelHandlers()
+		<-done
+		return fmt.Errorf("consumer of queue %s was stopped before its in-flight messages were handled", c.Queue)
+	}
+}
@@ -0,0 +1,161 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-boilerplate/repository/queue"
+	"go-boilerplate/worker/consumer"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRun(t *testing.T) {
+	msg := queue.Received{
+		Message: queue.Message{
+			Body:       `{"any":"body"}`,
+			Attributes: map[string]string{"type": "any"},
+		},
+		ID:            "message-id",
+		ReceiptHandle: "receipt-handle",
+		ReceiveCount:  1,
+	}
+	tooManyReceives := msg
+	tooManyReceives.ReceiveCount = 4
+
+	testCases := []struct {
+		name           string
+		msg            queue.Received
+		handler        consumer.Handler
+		configureMocks func(messagesMock *queue.MockConsumer)
+	}{
+		{
+			name: "message handled and deleted",
+			msg:  msg,
+			handler: func(ctx context.Context, msg queue.Received) error {
+				return nil
+			},
+			configureMocks: func(messagesMock *queue.MockConsumer) {
+				messagesMock.On("Delete", mock.Anything, "queue", msg.ReceiptHandle).Return(nil).Once()
+			},
+		},
+		{
+			name: "failed message kept to be received again",
+			msg:  msg,
+			handler: func(ctx context.Context, msg queue.Received) error {
+				return errors.New("database unavailable")
+			},
+			configureMocks: func(messagesMock *queue.MockConsumer) {},
+		},
+		{
+			name: "panic recovered and message kept to be received again",
+			msg:  msg,
+			handler: func(ctx context.Context, msg queue.Received) error {
+				panic("unexpected")
+			},
+			configureMocks: func(messagesMock *queue.MockConsumer) {},
+		},
+		{
+			name: "poison message routed to dead letter queue",
+			msg:  msg,
+			handler: func(ctx context.Context, msg queue.Received) error {
+				return fmt.Errorf("%w: invalid body", consumer.ErrPoison)
+			},
+			configureMocks: func(messagesMock *queue.MockConsumer) {
+				messagesMock.On("Send", mock.Anything, "queue-dlq", queue.Message{
+					Body: msg.Body,
+					Attributes: map[string]string{
+						"type":  "any",
+						"error": "poison message: invalid body",
+					},
+				}).Return(nil).Once()
+				messagesMock.On("Delete", mock.Anything, "queue", msg.ReceiptHandle).Return(nil).Once()
+			},
+		},
+		{
+			name: "message received too many times routed to dead letter queue",
+			msg:  tooManyReceives,
+			handler: func(ctx context.Context, msg queue.Received) error {
+				t.Errorf("unexpected message handled")
+				return nil
+			},
+			configureMocks: func(messagesMock *queue.MockConsumer) {
+				messagesMock.On("Send", mock.Anything, "queue-dlq", queue.Message{
+					Body: msg.Body,
+					Attributes: map[string]string{
+						"type":  "any",
+						"error": "poison message: received 4 times",
+					},
+				}).Return(nil).Once()
+				messagesMock.On("Delete", mock.Anything, "queue", msg.ReceiptHandle).Return(nil).Once()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			messagesMock := &queue.MockConsumer{}
+			messagesMock.On("Receive", mock.Anything, "queue", 2, time.Second, time.Minute).Return([]queue.Received{tc.msg}, nil).Once()
+			messagesMock.On("Receive", mock.Anything, "queue", mock.Anything, time.Second, time.Minute).Run(func(mock.Arguments) {
+				cancel()
+			}).Return(nil, context.Canceled)
+			tc.configureMocks(messagesMock)
+
+			c := consumer.Consumer{
+				Queue:           "queue",
+				DeadLetterQueue: "queue-dlq",
+				Handler:         tc.handler,
+				Messages:        messagesMock,
+				Concurrency:     2,
+				MaxReceives:     3,
+				Wait:            time.Second,
+				Visibility:      time.Minute,
+				ShutdownTimeout: time.Second,
+			}
+			err := c.Run(ctx)
+			if err != nil {
+				t.Errorf("unexpected error running consumer %s", err)
+			}
+
+			messagesMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunExtendsVisibility(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msg := queue.Received{ID: "message-id", ReceiptHandle: "receipt-handle", ReceiveCount: 1}
+
+	messagesMock := &queue.MockConsumer{}
+	messagesMock.On("Receive", mock.Anything, "queue", 1, time.Second, 2*time.Second).Return([]queue.Received{msg}, nil).Once()
+	messagesMock.On("Receive", mock.Anything, "queue", 1, time.Second, 2*time.Second).Run(func(mock.Arguments) {
+		cancel()
+	}).Return(nil, context.Canceled)
+	messagesMock.On("ExtendVisibility", mock.Anything, "queue", msg.ReceiptHandle, 2*time.Second).Return(nil)
+	messagesMock.On("Delete", mock.Anything, "queue", msg.ReceiptHandle).Return(nil).Once()
+
+	c := consumer.Consumer{
+		Queue:           "queue",
+		DeadLetterQueue: "queue-dlq",
+		Handler: func(ctx context.Context, msg queue.Received) error {
+			time.Sleep(1500 * time.Millisecond)
+			return nil
+		},
+		Messages:        messagesMock,
+		Concurrency:     1,
+		Wait:            time.Second,
+		Visibility:      2 * time.Second,
+		ShutdownTimeout: 5 * time.Second,
+	}
+	err := c.Run(ctx)
+	if err != nil {
+		t.Errorf("unexpected error running consumer %s", err)
+	}
+
+	messagesMock.AssertExpectations(t)
+}
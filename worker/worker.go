@@ -0,0 +1,90 @@
+// Package worker holds queue consumers setup
+package worker
+
+import (
+	"context"
+	"fmt"
+	"go-boilerplate/common"
+	"go-boilerplate/repository/queue"
+	comment "go-boilerplate/worker/comment/v1"
+	"go-boilerplate/worker/consumer"
+	"sync"
+	"time"
+)
+
+var (
+	concurrency     = common.Config.GetInt("workerConcurrency")
+	maxReceives     = common.Config.GetInt("workerMaxReceives")
+	wait            = time.Duration(common.Config.GetInt64("workerWaitSeconds")) * time.Second
+	visibility      = time.Duration(common.Config.GetInt64("workerVisibilityTimeoutSeconds")) * time.Second
+	shutdownTimeout = time.Duration(common.Config.GetInt64("workerShutdownTimeoutSeconds")) * time.Second
+)
+
+// Queues of all registered consumers
+func Queues() []string {
+	queues := []string{}
+	for _, c := range consumers() {
+		queues = append(queues, c.Queue)
+	}
+	return queues
+}
+
+// Setup runs the consumers of the given queues until the given context is done,
+// then waits for their in-flight messages within the shutdown timeout
+func Setup(ctx context.Context, queues []string) error {
+	registered := map[string]*consumer.Consumer{}
+	for _, c := range consumers() {
+		registered[c.Queue] = c
+	}
+
+	selected := []*consumer.Consumer{}
+	for _, q := range queues {
+		c, ok := registered[q]
+		if !ok {
+			return fmt.Errorf("no consumer registered for queue %s", q)
+		}
+		selected = append(selected, c)
+	}
+
+	errs := make(chan error, len(selected))
+	wg := sync.WaitGroup{}
+	for _, c := range selected {
+		wg.Add(1)
+		go func(c *consumer.Consumer) {
+			defer wg.Done()
+			common.Logger.Infof("consuming queue %s", c.Queue)
+			errs <- c.Run(ctx)
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	common.Logger.Info("worker stopped")
+
+	return nil
+}
+
+func consumers() []*consumer.Consumer {
+	return []*consumer.Consumer{
+		newConsumer(common.Config.Get("commentImportQueue"), common.Config.Get("commentImportDeadLetterQueue"), comment.CommentImportHandler),
+	}
+}
+
+func newConsumer(queueName, deadLetterQueue string, handler consumer.Handler) *consumer.Consumer {
+	return &consumer.Consumer{
+		Queue:           queueName,
+		DeadLetterQueue: deadLetterQueue,
+		Handler:         handler,
+		Messages:        queue.GetConsumer(),
+		Concurrency:     concurrency,
+		MaxReceives:     maxReceives,
+		Wait:            wait,
+		Visibility:      visibility,
+		ShutdownTimeout: shutdownTimeout,
+	}
+}